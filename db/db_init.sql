@@ -1,7 +1,28 @@
 CREATE DATABASE IF NOT EXISTS rede_social;
 USE rede_social;
 
+DROP TABLE IF EXISTS notificacoes_atores;
+DROP TABLE IF EXISTS sugestoes_dispensadas;
+DROP TABLE IF EXISTS sugestoes_para_seguir;
+DROP TABLE IF EXISTS anexos;
+DROP TABLE IF EXISTS pedidos_para_seguir;
+DROP TABLE IF EXISTS palavras_silenciadas;
+DROP TABLE IF EXISTS silenciados;
+DROP TABLE IF EXISTS bloqueios;
+DROP TABLE IF EXISTS mensagens;
+DROP TABLE IF EXISTS participantes_conversas;
+DROP TABLE IF EXISTS conversas;
+DROP TABLE IF EXISTS notificacoes;
+DROP TABLE IF EXISTS hashtags_em_alta;
+DROP TABLE IF EXISTS hashtags_publicacoes;
+DROP TABLE IF EXISTS mencoes;
+DROP TABLE IF EXISTS republicacoes;
+DROP TABLE IF EXISTS comentarios;
+DROP TABLE IF EXISTS curtidas;
+DROP TABLE IF EXISTS revisoes_publicacoes;
 DROP TABLE IF EXISTS publicacoes;
+DROP TABLE IF EXISTS membros_audiencias;
+DROP TABLE IF EXISTS audiencias;
 DROP TABLE IF EXISTS seguidores;
 DROP TABLE IF EXISTS usuarios;
 
@@ -11,7 +32,31 @@ CREATE TABLE usuarios(
     nick varchar(40) not null unique,
     email varchar(40) not null unique,
     senha varchar(100) not null,
-    criadoem timestamp default current_timestamp()
+    criadoem timestamp default current_timestamp(),
+    versao int unsigned not null default 1,
+    nome_busca varchar(40) not null,
+    nick_busca varchar(40) not null,
+    mensagensDe varchar(10) not null default 'todos',
+    privado boolean not null default false,
+    email_publico boolean not null default false,
+    admin boolean not null default false,
+    bio varchar(160) not null default '',
+    site varchar(100) not null default '',
+    localizacao varchar(30) not null default '',
+    pronomes varchar(30) not null default '',
+    avatar_id int null default null,
+    banner_id int null default null,
+    seguidores int not null default 0,
+    seguindo int not null default 0,
+    publicacoes int not null default 0,
+    sugestoes_pendentes boolean not null default false,
+    sugestoes_dos_seguidores_pendentes boolean not null default false,
+    INDEX idx_usuarios_seguidores (seguidores),
+    INDEX idx_usuarios_sugestoes_pendentes (sugestoes_pendentes),
+    INDEX idx_usuarios_sugestoes_dos_seguidores_pendentes (sugestoes_dos_seguidores_pendentes),
+    INDEX idx_usuarios_nick_busca (nick_busca, nick, nome),
+    INDEX idx_usuarios_nome_busca (nome_busca, nick, nome),
+    FULLTEXT INDEX idx_usuarios_nome_fulltext (nome_busca)
 ) ENGINE=INNODB;
 
 CREATE TABLE seguidores(
@@ -22,6 +67,23 @@ CREATE TABLE seguidores(
     primary key(usuario_id, seguidor_id)
 ) ENGINE=INNODB;
 
+CREATE TABLE audiencias(
+    id int auto_increment primary KEY,
+    dono_id int not null,
+    FOREIGN KEY (dono_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    nome varchar(50) not null,
+    criadoEm TIMESTAMP default CURRENT_TIMESTAMP,
+    INDEX idx_audiencias_dono (dono_id)
+) ENGINE=INNODB;
+
+CREATE TABLE membros_audiencias(
+    audiencia_id int not null,
+    FOREIGN KEY (audiencia_id) REFERENCES audiencias(id) ON DELETE CASCADE,
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    primary key (audiencia_id, usuario_id)
+) ENGINE=INNODB;
+
 CREATE TABLE publicacoes(
     id int auto_increment primary KEY,
     titulo varchar(50) not null,
@@ -29,5 +91,232 @@ CREATE TABLE publicacoes(
     autor_id int not null,
     FOREIGN KEY (autor_id) REFERENCES usuarios(id) ON DELETE CASCADE,
     curtidas int default 0,
+    comentarios int default 0,
+    republicacoes int default 0,
+    citacoes int default 0,
+    citada_id int null,
+    entidades json null,
+    FOREIGN KEY (citada_id) REFERENCES publicacoes(id) ON DELETE SET NULL,
+    visibilidade varchar(12) not null default 'publico',
+    audiencia_id int null,
+    FOREIGN KEY (audiencia_id) REFERENCES audiencias(id) ON DELETE SET NULL,
+    criadoEm TIMESTAMP default CURRENT_TIMESTAMP,
+    versao int unsigned not null default 1,
+    editadoEm TIMESTAMP null default null,
+    deletadoEm TIMESTAMP null default null,
+    INDEX idx_publicacoes_deletado (deletadoEm),
+    INDEX idx_publicacoes_autor (autor_id, criadoEm),
+    FULLTEXT INDEX idx_publicacoes_busca (titulo, conteudo)
+) ENGINE=INNODB;
+
+CREATE TABLE revisoes_publicacoes(
+    id int auto_increment primary KEY,
+    publicacao_id int not null,
+    FOREIGN KEY (publicacao_id) REFERENCES publicacoes(id) ON DELETE CASCADE,
+    titulo varchar(50) not null,
+    conteudo varchar(300) not null,
     criadoEm TIMESTAMP default CURRENT_TIMESTAMP
-) ENGINE=INNODB;
\ No newline at end of file
+) ENGINE=INNODB;
+
+CREATE TABLE curtidas(
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    publicacao_id int not null,
+    FOREIGN KEY (publicacao_id) REFERENCES publicacoes(id) ON DELETE CASCADE,
+    criadoEm TIMESTAMP default CURRENT_TIMESTAMP,
+    primary key(usuario_id, publicacao_id),
+    INDEX idx_curtidas_publicacao (publicacao_id, criadoEm)
+) ENGINE=INNODB;
+
+CREATE TABLE comentarios(
+    id int auto_increment primary KEY,
+    publicacao_id int not null,
+    FOREIGN KEY (publicacao_id) REFERENCES publicacoes(id) ON DELETE CASCADE,
+    autor_id int null,
+    FOREIGN KEY (autor_id) REFERENCES usuarios(id) ON DELETE SET NULL,
+    pai_id int null,
+    FOREIGN KEY (pai_id) REFERENCES comentarios(id) ON DELETE CASCADE,
+    profundidade int not null default 0,
+    caminho varchar(255) not null,
+    conteudo varchar(300) not null,
+    criadoEm TIMESTAMP default CURRENT_TIMESTAMP,
+    editadoEm TIMESTAMP null default null,
+    deletadoEm TIMESTAMP null default null,
+    INDEX idx_comentarios_arvore (publicacao_id, caminho)
+) ENGINE=INNODB;
+
+CREATE TABLE republicacoes(
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    publicacao_id int not null,
+    FOREIGN KEY (publicacao_id) REFERENCES publicacoes(id) ON DELETE CASCADE,
+    criadoEm TIMESTAMP default CURRENT_TIMESTAMP,
+    primary key (usuario_id, publicacao_id),
+    INDEX idx_republicacoes_publicacao (publicacao_id)
+) ENGINE=INNODB;
+
+CREATE TABLE mencoes(
+    publicacao_id int not null,
+    FOREIGN KEY (publicacao_id) REFERENCES publicacoes(id) ON DELETE CASCADE,
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    primary key (publicacao_id, usuario_id),
+    INDEX idx_mencoes_usuario (usuario_id, publicacao_id)
+) ENGINE=INNODB;
+
+CREATE TABLE hashtags_publicacoes(
+    publicacao_id int not null,
+    FOREIGN KEY (publicacao_id) REFERENCES publicacoes(id) ON DELETE CASCADE,
+    tag varchar(100) not null,
+    criadoEm TIMESTAMP not null,
+    primary key (publicacao_id, tag),
+    INDEX idx_hashtags_tag (tag, publicacao_id),
+    INDEX idx_hashtags_criado (criadoEm)
+) ENGINE=INNODB;
+
+CREATE TABLE hashtags_em_alta(
+    tag varchar(100) primary key,
+    pontuacao double not null,
+    publicacoes int not null,
+    publicacoesAntes int not null,
+    calculadoEm TIMESTAMP default CURRENT_TIMESTAMP
+) ENGINE=INNODB;
+
+CREATE TABLE notificacoes(
+    id int auto_increment primary KEY,
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    tipo varchar(20) not null,
+    chave varchar(60) not null,
+    publicacao_id int null,
+    FOREIGN KEY (publicacao_id) REFERENCES publicacoes(id) ON DELETE CASCADE,
+    comentario_id int null,
+    FOREIGN KEY (comentario_id) REFERENCES comentarios(id) ON DELETE CASCADE,
+    quantidade int not null default 1,
+    ultimo_ator_id int not null,
+    FOREIGN KEY (ultimo_ator_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    lida boolean not null default false,
+    criadoEm TIMESTAMP default CURRENT_TIMESTAMP,
+    atualizadoEm TIMESTAMP default CURRENT_TIMESTAMP,
+    chave_aberta varchar(60) as (if(lida, null, chave)) stored,
+    UNIQUE INDEX idx_notificacoes_abertas (usuario_id, chave_aberta),
+    INDEX idx_notificacoes_usuario (usuario_id, atualizadoEm)
+) ENGINE=INNODB;
+
+CREATE TABLE conversas(
+    id int auto_increment primary KEY,
+    nome varchar(50) null,
+    grupo boolean not null default false,
+    chave varchar(30) null unique,
+    criadoEm TIMESTAMP default CURRENT_TIMESTAMP,
+    atualizadoEm TIMESTAMP default CURRENT_TIMESTAMP
+) ENGINE=INNODB;
+
+CREATE TABLE participantes_conversas(
+    conversa_id int not null,
+    FOREIGN KEY (conversa_id) REFERENCES conversas(id) ON DELETE CASCADE,
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    lidoAte int not null default 0,
+    entrouEm TIMESTAMP default CURRENT_TIMESTAMP,
+    primary key (conversa_id, usuario_id),
+    INDEX idx_participantes_usuario (usuario_id)
+) ENGINE=INNODB;
+
+CREATE TABLE mensagens(
+    id int auto_increment primary KEY,
+    conversa_id int not null,
+    FOREIGN KEY (conversa_id) REFERENCES conversas(id) ON DELETE CASCADE,
+    remetente_id int not null,
+    FOREIGN KEY (remetente_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    conteudo varchar(1000) not null,
+    criadoEm TIMESTAMP default CURRENT_TIMESTAMP,
+    deletadoEm TIMESTAMP null default null,
+    INDEX idx_mensagens_conversa (conversa_id, id)
+) ENGINE=INNODB;
+
+CREATE TABLE bloqueios(
+    bloqueador_id int not null,
+    FOREIGN KEY (bloqueador_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    bloqueado_id int not null,
+    FOREIGN KEY (bloqueado_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    criadoEm TIMESTAMP default CURRENT_TIMESTAMP,
+    primary key (bloqueador_id, bloqueado_id),
+    INDEX idx_bloqueios_bloqueado (bloqueado_id, bloqueador_id)
+) ENGINE=INNODB;
+
+CREATE TABLE silenciados(
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    silenciado_id int not null,
+    FOREIGN KEY (silenciado_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    ate TIMESTAMP null default null,
+    criadoEm TIMESTAMP default CURRENT_TIMESTAMP,
+    primary key (usuario_id, silenciado_id),
+    INDEX idx_silenciados_ate (ate)
+) ENGINE=INNODB;
+
+CREATE TABLE palavras_silenciadas(
+    id int auto_increment primary KEY,
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    palavra varchar(100) not null,
+    ate TIMESTAMP null default null,
+    criadoEm TIMESTAMP default CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_palavras_silenciadas_usuario (usuario_id, palavra),
+    INDEX idx_palavras_silenciadas_ate (ate)
+) ENGINE=INNODB;
+
+CREATE TABLE pedidos_para_seguir(
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    seguidor_id int not null,
+    FOREIGN KEY (seguidor_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    criadoEm TIMESTAMP default CURRENT_TIMESTAMP,
+    primary key (usuario_id, seguidor_id)
+) ENGINE=INNODB;
+
+CREATE TABLE anexos(
+    id int auto_increment primary KEY,
+    publicacao_id int null default null,
+    FOREIGN KEY (publicacao_id) REFERENCES publicacoes(id) ON DELETE SET NULL,
+    dono_id int not null,
+    FOREIGN KEY (dono_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    tipo varchar(20) not null,
+    largura int not null,
+    altura int not null,
+    tamanho int not null,
+    texto_alternativo varchar(1000) not null default '',
+    chave varchar(255) not null,
+    chave_miniatura varchar(255) not null,
+    posicao int not null default 0,
+    criadoEm TIMESTAMP default CURRENT_TIMESTAMP,
+    INDEX idx_anexos_publicacao (publicacao_id, posicao)
+) ENGINE=INNODB;
+
+CREATE TABLE sugestoes_para_seguir(
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    sugerido_id int not null,
+    FOREIGN KEY (sugerido_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    pontos int not null,
+    primary key (usuario_id, sugerido_id),
+    INDEX idx_sugestoes_para_seguir_pontos (usuario_id, pontos)
+) ENGINE=INNODB;
+
+CREATE TABLE sugestoes_dispensadas(
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    dispensado_id int not null,
+    FOREIGN KEY (dispensado_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    criadoEm TIMESTAMP default CURRENT_TIMESTAMP,
+    primary key (usuario_id, dispensado_id)
+) ENGINE=INNODB;
+
+CREATE TABLE notificacoes_atores(
+    notificacao_id int not null,
+    FOREIGN KEY (notificacao_id) REFERENCES notificacoes(id) ON DELETE CASCADE,
+    ator_id int not null,
+    FOREIGN KEY (ator_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+    primary key (notificacao_id, ator_id)
+) ENGINE=INNODB;
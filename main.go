@@ -1,11 +1,20 @@
 package main
 
 import (
+	"api/src/armazenamento"
+	"api/src/banco"
+	"api/src/cache"
 	"api/src/config"
+	"api/src/eventos"
+	"api/src/modelos"
+	"api/src/repositorios"
 	"api/src/router"
+	"api/src/semente"
+	"api/src/tarefas"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 )
 
 //Gerando chave aleatória de 64 bits para geração de tokens
@@ -21,9 +30,47 @@ import (
 
 func main() {
 	config.Carregar()
+	//api seed enche o banco com dados sintéticos em vez de subir o servidor
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		if erro := semente.Executar(os.Args[2:]); erro != nil {
+			log.Fatal(erro)
+		}
+		return
+	}
+	if erro := cache.Configurar(); erro != nil {
+		log.Fatal(erro)
+	}
+	if erro := armazenamento.Configurar(); erro != nil {
+		log.Fatal(erro)
+	}
+
+	if config.BuscaBackend == "memoria" {
+		if erro := carregarIndiceDeBusca(); erro != nil {
+			log.Fatal(erro)
+		}
+	}
+
+	//as notificações criadas são empurradas na hora para quem estiver conectado em /eventos
+	repositorios.AoNotificar = func(notificacao modelos.Notificacao) {
+		if erro := eventos.Publicar([]uint64{notificacao.UsuarioID}, "notificacao", notificacao); erro != nil {
+			log.Printf("erro ao transmitir notificação: %v", erro)
+		}
+	}
+
+	tarefas.Iniciar()
 
 	r := router.Gerar()
 
 	fmt.Printf("Escutando na porta %d", config.Porta)
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", config.Porta), r))
 }
+
+// carregarIndiceDeBusca monta o índice de busca em memória com as publicações que já estão no banco
+func carregarIndiceDeBusca() error {
+	db, erro := banco.Conectar()
+	if erro != nil {
+		return erro
+	}
+	defer db.Close()
+	return repositorios.CarregarIndiceEmMemoria(db)
+}
@@ -0,0 +1,65 @@
+package armazenamento
+
+import (
+	"api/src/config"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErroNaoEncontrado é retornado ao abrir ou remover um arquivo que não existe
+var ErroNaoEncontrado = errors.New("arquivo não encontrado")
+
+// Armazenamento é onde a aplicação guarda os arquivos enviados pelos usuários, identificados por uma chave
+type Armazenamento interface {
+	//Salvar grava conteudo em chave, substituindo o que houver
+	Salvar(chave string, conteudo []byte, tipo string) error
+	//Abrir traz o conteúdo e o tipo do arquivo salvo em chave, quem chama deve fechar o leitor
+	Abrir(chave string) (io.ReadCloser, string, error)
+	//Remover apaga o arquivo de chave, remover um arquivo inexistente não é erro
+	Remover(chave string) error
+}
+
+var (
+	//atual é o armazenamento usado pela aplicação, começa no diretório padrão até Configurar ser chamado
+	atual Armazenamento = NovoLocal("arquivos")
+)
+
+// Configurar escolhe o armazenamento de acordo com as variáveis de ambiente carregadas em config
+func Configurar() error {
+	switch config.ArmazenamentoBackend {
+	case "", "local":
+		atual = NovoLocal(config.ArmazenamentoDiretorio)
+	case "s3":
+		if config.S3Endpoint == "" || config.S3Bucket == "" {
+			return errors.New("o armazenamento s3 precisa de S3_ENDPOINT e S3_BUCKET")
+		}
+		atual = NovoS3(config.S3Endpoint, config.S3Regiao, config.S3Bucket, config.S3ChaveDeAcesso, config.S3Segredo)
+	default:
+		return fmt.Errorf("armazenamento %q desconhecido, use local ou s3", config.ArmazenamentoBackend)
+	}
+	return nil
+}
+
+// Salvar grava conteudo em chave no armazenamento configurado
+func Salvar(chave string, conteudo []byte, tipo string) error {
+	return atual.Salvar(chave, conteudo, tipo)
+}
+
+// Abrir traz o arquivo salvo em chave no armazenamento configurado
+func Abrir(chave string) (io.ReadCloser, string, error) {
+	return atual.Abrir(chave)
+}
+
+// Remover apaga os arquivos das chaves no armazenamento configurado, chaves vazias são ignoradas
+func Remover(chaves ...string) error {
+	for _, chave := range chaves {
+		if chave == "" {
+			continue
+		}
+		if erro := atual.Remover(chave); erro != nil {
+			return erro
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package armazenamento
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// extensaoDoTipo guarda o tipo do arquivo ao lado dele, já que o sistema de arquivos não tem onde guardar isso
+const extensaoDoTipo = ".tipo"
+
+// Local guarda os arquivos numa pasta do próprio servidor
+type Local struct {
+	diretorio string
+}
+
+// NovoLocal cria um armazenamento que guarda os arquivos em diretorio, criado no primeiro Salvar se não existir
+func NovoLocal(diretorio string) *Local {
+	return &Local{diretorio}
+}
+
+// caminho transforma a chave num caminho dentro do diretório, recusando chaves que sairiam dele
+func (local *Local) caminho(chave string) (string, error) {
+	limpa := filepath.Clean("/" + chave)
+	if chave == "" || limpa == "/" || strings.Contains(chave, "..") {
+		return "", errors.New("chave de arquivo inválida")
+	}
+	return filepath.Join(local.diretorio, filepath.FromSlash(limpa)), nil
+}
+
+// Salvar grava conteudo num arquivo temporário e renomeia, assim quem lê nunca vê um arquivo pela metade
+func (local *Local) Salvar(chave string, conteudo []byte, tipo string) error {
+	caminho, erro := local.caminho(chave)
+	if erro != nil {
+		return erro
+	}
+	if erro = os.MkdirAll(filepath.Dir(caminho), 0o755); erro != nil {
+		return erro
+	}
+	if erro = escreverAtomico(caminho+extensaoDoTipo, []byte(tipo)); erro != nil {
+		return erro
+	}
+	return escreverAtomico(caminho, conteudo)
+}
+
+// escreverAtomico escreve conteudo em caminho passando por um arquivo temporário na mesma pasta
+func escreverAtomico(caminho string, conteudo []byte) error {
+	temporario, erro := os.CreateTemp(filepath.Dir(caminho), ".envio-*")
+	if erro != nil {
+		return erro
+	}
+	defer os.Remove(temporario.Name())
+	if _, erro = temporario.Write(conteudo); erro != nil {
+		temporario.Close()
+		return erro
+	}
+	if erro = temporario.Close(); erro != nil {
+		return erro
+	}
+	return os.Rename(temporario.Name(), caminho)
+}
+
+// Abrir abre o arquivo de chave para leitura
+func (local *Local) Abrir(chave string) (io.ReadCloser, string, error) {
+	caminho, erro := local.caminho(chave)
+	if erro != nil {
+		return nil, "", erro
+	}
+	arquivo, erro := os.Open(caminho)
+	if errors.Is(erro, fs.ErrNotExist) {
+		return nil, "", ErroNaoEncontrado
+	}
+	if erro != nil {
+		return nil, "", erro
+	}
+	tipo, erro := os.ReadFile(caminho + extensaoDoTipo)
+	if erro != nil {
+		tipo = []byte("application/octet-stream")
+	}
+	return arquivo, string(tipo), nil
+}
+
+// Remover apaga o arquivo de chave e o tipo guardado ao lado dele
+func (local *Local) Remover(chave string) error {
+	caminho, erro := local.caminho(chave)
+	if erro != nil {
+		return erro
+	}
+	for _, arquivo := range []string{caminho, caminho + extensaoDoTipo} {
+		if erro = os.Remove(arquivo); erro != nil && !errors.Is(erro, fs.ErrNotExist) {
+			return erro
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package armazenamento
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3 guarda os arquivos num bucket de um serviço compatível com S3 (AWS, MinIO...).
+// Usa endereços no estilo caminho (endpoint/bucket/chave), aceito por todos eles,
+// e assina as requisições com AWS Signature Version 4
+type S3 struct {
+	endpoint      *url.URL
+	regiao        string
+	bucket        string
+	chaveDeAcesso string
+	segredo       string
+	cliente       *http.Client
+}
+
+// NovoS3 cria um armazenamento no bucket do serviço S3 em endpoint
+func NovoS3(endpoint, regiao, bucket, chaveDeAcesso, segredo string) *S3 {
+	endereco, erro := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if erro != nil || endereco.Host == "" {
+		//um endpoint sem esquema, como localhost:9000, é tratado como http
+		endereco = &url.URL{Scheme: "http", Host: endpoint}
+	}
+	return &S3{
+		endpoint:      endereco,
+		regiao:        regiao,
+		bucket:        bucket,
+		chaveDeAcesso: chaveDeAcesso,
+		segredo:       segredo,
+		cliente:       &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Salvar envia conteudo para o bucket em chave
+func (s3 *S3) Salvar(chave string, conteudo []byte, tipo string) error {
+	resposta, erro := s3.requisitar(http.MethodPut, chave, conteudo, tipo)
+	if erro != nil {
+		return erro
+	}
+	defer resposta.Body.Close()
+	if resposta.StatusCode != http.StatusOK {
+		return erroDoS3(resposta)
+	}
+	return nil
+}
+
+// Abrir baixa o arquivo de chave do bucket
+func (s3 *S3) Abrir(chave string) (io.ReadCloser, string, error) {
+	resposta, erro := s3.requisitar(http.MethodGet, chave, nil, "")
+	if erro != nil {
+		return nil, "", erro
+	}
+	switch resposta.StatusCode {
+	case http.StatusOK:
+		return resposta.Body, resposta.Header.Get("Content-Type"), nil
+	case http.StatusNotFound:
+		resposta.Body.Close()
+		return nil, "", ErroNaoEncontrado
+	default:
+		defer resposta.Body.Close()
+		return nil, "", erroDoS3(resposta)
+	}
+}
+
+// Remover apaga o arquivo de chave do bucket. O S3 responde sucesso mesmo se ele não existir
+func (s3 *S3) Remover(chave string) error {
+	resposta, erro := s3.requisitar(http.MethodDelete, chave, nil, "")
+	if erro != nil {
+		return erro
+	}
+	defer resposta.Body.Close()
+	if resposta.StatusCode != http.StatusNoContent && resposta.StatusCode != http.StatusOK &&
+		resposta.StatusCode != http.StatusNotFound {
+		return erroDoS3(resposta)
+	}
+	return nil
+}
+
+// erroDoS3 monta um erro com o status e o começo do corpo da resposta, onde o S3 explica o que houve
+func erroDoS3(resposta *http.Response) error {
+	corpo, _ := io.ReadAll(io.LimitReader(resposta.Body, 512))
+	return fmt.Errorf("s3 respondeu %s: %s", resposta.Status, strings.TrimSpace(string(corpo)))
+}
+
+// requisitar faz uma requisição assinada ao objeto chave do bucket
+func (s3 *S3) requisitar(metodo, chave string, conteudo []byte, tipo string) (*http.Response, error) {
+	caminho := strings.TrimSuffix(s3.endpoint.Path, "/") + "/" + codificarCaminho(s3.bucket) + "/" + codificarCaminho(chave)
+	endereco := *s3.endpoint
+	endereco.RawPath = caminho
+	endereco.Path, _ = url.PathUnescape(caminho)
+	requisicao, erro := http.NewRequest(metodo, endereco.String(), bytes.NewReader(conteudo))
+	if erro != nil {
+		return nil, erro
+	}
+	if tipo != "" {
+		requisicao.Header.Set("Content-Type", tipo)
+	}
+	s3.assinar(requisicao, caminho, conteudo)
+	return s3.cliente.Do(requisicao)
+}
+
+// assinar adiciona os cabeçalhos da AWS Signature Version 4 à requisição.
+// Só host, x-amz-content-sha256 e x-amz-date entram na assinatura, que é o mínimo exigido
+func (s3 *S3) assinar(requisicao *http.Request, caminho string, conteudo []byte) {
+	agora := time.Now().UTC()
+	dataEHora := agora.Format("20060102T150405Z")
+	data := agora.Format("20060102")
+	hashDoConteudo := hexSHA256(conteudo)
+	requisicao.Header.Set("X-Amz-Date", dataEHora)
+	requisicao.Header.Set("X-Amz-Content-Sha256", hashDoConteudo)
+
+	cabecalhosAssinados := "host;x-amz-content-sha256;x-amz-date"
+	requisicaoCanonica := strings.Join([]string{
+		requisicao.Method,
+		caminho,
+		"",
+		"host:" + requisicao.URL.Host,
+		"x-amz-content-sha256:" + hashDoConteudo,
+		"x-amz-date:" + dataEHora,
+		"",
+		cabecalhosAssinados,
+		hashDoConteudo,
+	}, "\n")
+	escopo := data + "/" + s3.regiao + "/s3/aws4_request"
+	textoParaAssinar := "AWS4-HMAC-SHA256\n" + dataEHora + "\n" + escopo + "\n" + hexSHA256([]byte(requisicaoCanonica))
+
+	chave := hmacSHA256([]byte("AWS4"+s3.segredo), data)
+	chave = hmacSHA256(chave, s3.regiao)
+	chave = hmacSHA256(chave, "s3")
+	chave = hmacSHA256(chave, "aws4_request")
+	assinatura := hex.EncodeToString(hmacSHA256(chave, textoParaAssinar))
+
+	requisicao.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3.chaveDeAcesso, escopo, cabecalhosAssinados, assinatura))
+}
+
+// codificarCaminho codifica cada parte do caminho como a AWS espera: só letras, números e -_.~ ficam como estão
+func codificarCaminho(caminho string) string {
+	var codificado strings.Builder
+	for _, b := range []byte(caminho) {
+		switch {
+		case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~', b == '/':
+			codificado.WriteByte(b)
+		default:
+			fmt.Fprintf(&codificado, "%%%02X", b)
+		}
+	}
+	return codificado.String()
+}
+
+// hexSHA256 é o sha256 de conteudo em hexadecimal, como a assinatura pede
+func hexSHA256(conteudo []byte) string {
+	hash := sha256.Sum256(conteudo)
+	return hex.EncodeToString(hash[:])
+}
+
+// hmacSHA256 assina texto com chave, cada passo da derivação da chave de assinatura usa isso
+func hmacSHA256(chave []byte, texto string) []byte {
+	mac := hmac.New(sha256.New, chave)
+	mac.Write([]byte(texto))
+	return mac.Sum(nil)
+}
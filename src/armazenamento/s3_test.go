@@ -0,0 +1,145 @@
+package armazenamento
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// s3Falso é um bucket em memória que só aceita requisições assinadas com AWS Signature Version 4 pela chave dele
+type s3Falso struct {
+	regiao, chaveDeAcesso, segredo string
+	mutex                          sync.Mutex
+	objetos                        map[string]objetoFalso
+}
+
+type objetoFalso struct {
+	conteudo []byte
+	tipo     string
+}
+
+func (servidor *s3Falso) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	corpo, _ := io.ReadAll(r.Body)
+	if erro := servidor.conferirAssinatura(r, corpo); erro != "" {
+		http.Error(w, erro, http.StatusForbidden)
+		return
+	}
+	servidor.mutex.Lock()
+	defer servidor.mutex.Unlock()
+	caminho := r.URL.EscapedPath()
+	switch r.Method {
+	case http.MethodPut:
+		servidor.objetos[caminho] = objetoFalso{corpo, r.Header.Get("Content-Type")}
+	case http.MethodGet:
+		objeto, existe := servidor.objetos[caminho]
+		if !existe {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", objeto.tipo)
+		w.Write(objeto.conteudo)
+	case http.MethodDelete:
+		delete(servidor.objetos, caminho)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// conferirAssinatura refaz a assinatura do jeito que a AWS documenta e compara com o Authorization recebido.
+// Devolve o motivo da recusa, ou vazio se a assinatura bate
+func (servidor *s3Falso) conferirAssinatura(r *http.Request, corpo []byte) string {
+	hashDoCorpo := sha256.Sum256(corpo)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(hashDoCorpo[:]) {
+		return "x-amz-content-sha256 não bate com o corpo"
+	}
+	dataEHora := r.Header.Get("X-Amz-Date")
+	if len(dataEHora) != len("20060102T150405Z") {
+		return "x-amz-date ausente"
+	}
+	escopo := dataEHora[:8] + "/" + servidor.regiao + "/s3/aws4_request"
+	canonica := r.Method + "\n" + r.URL.EscapedPath() + "\n\n" +
+		"host:" + r.Host + "\nx-amz-content-sha256:" + r.Header.Get("X-Amz-Content-Sha256") + "\nx-amz-date:" + dataEHora + "\n\n" +
+		"host;x-amz-content-sha256;x-amz-date\n" + r.Header.Get("X-Amz-Content-Sha256")
+	hashDaCanonica := sha256.Sum256([]byte(canonica))
+	texto := "AWS4-HMAC-SHA256\n" + dataEHora + "\n" + escopo + "\n" + hex.EncodeToString(hashDaCanonica[:])
+	chave := []byte("AWS4" + servidor.segredo)
+	for _, parte := range []string{dataEHora[:8], servidor.regiao, "s3", "aws4_request", texto} {
+		mac := hmac.New(sha256.New, chave)
+		mac.Write([]byte(parte))
+		chave = mac.Sum(nil)
+	}
+	esperado := "AWS4-HMAC-SHA256 Credential=" + servidor.chaveDeAcesso + "/" + escopo +
+		", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=" + hex.EncodeToString(chave)
+	if r.Header.Get("Authorization") != esperado {
+		return "SignatureDoesNotMatch"
+	}
+	return ""
+}
+
+// novoS3Falso sobe o bucket em memória num httptest.Server
+func novoS3Falso(t *testing.T) (*s3Falso, *httptest.Server) {
+	t.Helper()
+	servidor := &s3Falso{regiao: "sa-east-1", chaveDeAcesso: "CHAVE", segredo: "segredo", objetos: map[string]objetoFalso{}}
+	endereco := httptest.NewServer(servidor)
+	t.Cleanup(endereco.Close)
+	return servidor, endereco
+}
+
+func TestS3SalvarAbrirERemover(t *testing.T) {
+	servidor, endereco := novoS3Falso(t)
+	s3 := NovoS3(endereco.URL, servidor.regiao, "devbook", servidor.chaveDeAcesso, servidor.segredo)
+	//espaço e acento precisam ser codificados do mesmo jeito no caminho e na assinatura
+	chave := "anexos/2024/foto de férias.png"
+	conteudo := []byte("\x89PNG conteúdo da imagem")
+
+	if erro := s3.Salvar(chave, conteudo, "image/png"); erro != nil {
+		t.Fatal(erro)
+	}
+	if _, existe := servidor.objetos["/devbook/anexos/2024/foto%20de%20f%C3%A9rias.png"]; !existe {
+		t.Fatalf("o objeto não foi guardado no caminho esperado, o bucket tem %v", servidor.objetos)
+	}
+
+	arquivo, tipo, erro := s3.Abrir(chave)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	lido, erro := io.ReadAll(arquivo)
+	arquivo.Close()
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if !bytes.Equal(lido, conteudo) || tipo != "image/png" {
+		t.Errorf("esperava o conteúdo salvo com image/png, veio %q com %s", lido, tipo)
+	}
+
+	if erro = s3.Remover(chave); erro != nil {
+		t.Fatal(erro)
+	}
+	if _, _, erro = s3.Abrir(chave); erro != ErroNaoEncontrado {
+		t.Errorf("esperava ErroNaoEncontrado depois de remover, veio %v", erro)
+	}
+	//remover de novo não é erro
+	if erro = s3.Remover(chave); erro != nil {
+		t.Errorf("remover um arquivo que não existe deu erro: %v", erro)
+	}
+}
+
+func TestS3RecusadoComSegredoErrado(t *testing.T) {
+	servidor, endereco := novoS3Falso(t)
+	s3 := NovoS3(endereco.URL, servidor.regiao, "devbook", servidor.chaveDeAcesso, "outro segredo")
+	erro := s3.Salvar("anexos/1.png", []byte("conteúdo"), "image/png")
+	if erro == nil || !strings.Contains(erro.Error(), "SignatureDoesNotMatch") {
+		t.Errorf("esperava o erro de assinatura do s3, veio %v", erro)
+	}
+	if len(servidor.objetos) != 0 {
+		t.Error("uma requisição com a assinatura errada foi guardada")
+	}
+}
@@ -69,6 +69,25 @@ func extrairToken(r *http.Request) string {
 	return ""
 }
 
+// ProtocoloDoToken é o subprotocolo de WebSocket que indica que o token vem logo depois dele no Sec-WebSocket-Protocol,
+// já que o WebSocket do navegador não deixa mandar o cabeçalho Authorization
+const ProtocoloDoToken = "bearer"
+
+// ExtrairTokenDoNavegador traz o token de onde o navegador consegue mandar quando não há cabeçalho Authorization,
+// como no EventSource e no WebSocket: o parâmetro token da url ou o Sec-WebSocket-Protocol "bearer, <token>"
+func ExtrairTokenDoNavegador(r *http.Request) string {
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token
+	}
+	protocolos := strings.Split(r.Header.Get("Sec-WebSocket-Protocol"), ",")
+	for i := 0; i+1 < len(protocolos); i++ {
+		if strings.TrimSpace(protocolos[i]) == ProtocoloDoToken {
+			return strings.TrimSpace(protocolos[i+1])
+		}
+	}
+	return ""
+}
+
 // verificando se a chave recebida é da família da secret key
 func retornarChaveDeVerificacao(token *jwt.Token) (interface{}, error) {
 	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -3,13 +3,100 @@ package banco
 import (
 	"api/src/config"
 	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
+var (
+	//proximaReplica é usada para distribuir as leituras entre as réplicas em rodízio
+	proximaReplica atomic.Uint64
+	//ultimasEscritas guarda quando cada usuário escreveu no primário pela última vez
+	ultimasEscritas      = map[uint64]time.Time{}
+	mutexUltimasEscritas sync.Mutex
+	//Driver é o nome do driver com que o primário é aberto, os testes dos controllers trocam por um banco falso
+	Driver = "mysql"
+)
+
+// driverDeReplica é o driver do mysql registrado com outro nome para abrir as réplicas, assim EhReplica
+// reconhece de onde veio uma conexão
+type driverDeReplica struct{}
+
+func (driverDeReplica) Open(dsn string) (driver.Conn, error) {
+	return mysql.MySQLDriver{}.Open(dsn)
+}
+
+func init() {
+	sql.Register("mysql-replica", driverDeReplica{})
+}
+
 // Conectar abre uma conexao com db
 func Conectar() (*sql.DB, error) {
-	db, erro := sql.Open("mysql", config.Conexao)
+	return abrir(Driver, config.Conexao)
+}
+
+// EhReplica diz se db é uma réplica aberta por ConectarLeitura, que pode estar atrasada em relação ao primário
+func EhReplica(db *sql.DB) bool {
+	_, ehReplica := db.Driver().(driverDeReplica)
+	return ehReplica
+}
+
+// ConectarEscrita abre uma conexão com o primário para usuarioID escrever e faz as leituras dele
+// continuarem no primário pela janela configurada, assim ele vê o que acabou de escrever
+func ConectarEscrita(usuarioID uint64) (*sql.DB, error) {
+	mutexUltimasEscritas.Lock()
+	ultimasEscritas[usuarioID] = time.Now()
+	//de vez em quando limpando quem escreveu e nunca mais leu
+	if len(ultimasEscritas) > 10000 {
+		for id, ultimaEscrita := range ultimasEscritas {
+			if time.Since(ultimaEscrita) > config.JanelaLeituraNoPrimario {
+				delete(ultimasEscritas, id)
+			}
+		}
+	}
+	mutexUltimasEscritas.Unlock()
+	return Conectar()
+}
+
+// ConectarLeitura abre uma conexão para leituras feitas por usuarioID. Vai para uma das réplicas,
+// a não ser que não existam réplicas, que nenhuma responda ou que usuarioID tenha escrito há pouco tempo
+func ConectarLeitura(usuarioID uint64) (*sql.DB, error) {
+	if len(config.ConexoesReplicas) == 0 || escreveuRecentemente(usuarioID) {
+		return Conectar()
+	}
+	inicio := proximaReplica.Add(1)
+	for i := 0; i < len(config.ConexoesReplicas); i++ {
+		replica := config.ConexoesReplicas[(inicio+uint64(i))%uint64(len(config.ConexoesReplicas))]
+		if db, erro := abrir("mysql-replica", replica); erro == nil {
+			return db, nil
+		}
+	}
+	//se nenhuma réplica respondeu a leitura vai pro primário
+	return Conectar()
+}
+
+// escreveuRecentemente diz se usuarioID escreveu no primário dentro da janela configurada
+func escreveuRecentemente(usuarioID uint64) bool {
+	mutexUltimasEscritas.Lock()
+	defer mutexUltimasEscritas.Unlock()
+	ultimaEscrita, existe := ultimasEscritas[usuarioID]
+	if !existe {
+		return false
+	}
+	if time.Since(ultimaEscrita) > config.JanelaLeituraNoPrimario {
+		//janela passou, não precisa mais guardar
+		delete(ultimasEscritas, usuarioID)
+		return false
+	}
+	return true
+}
+
+// abrir abre e testa uma conexão com o dsn recebido usando o driver de nome nomeDoDriver
+func abrir(nomeDoDriver, dsn string) (*sql.DB, error) {
+	db, erro := sql.Open(nomeDoDriver, dsn)
 	if erro != nil {
 		return nil, erro
 	}
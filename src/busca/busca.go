@@ -0,0 +1,82 @@
+package busca
+
+import (
+	"math"
+	"time"
+)
+
+// MeiaVida é a idade em que a relevância de uma publicação cai pela metade na ordenação dos resultados
+const MeiaVida = 14 * 24 * time.Hour
+
+// Indice é um índice de texto das publicações
+type Indice interface {
+	//Indexar adiciona ou substitui o documento no índice
+	Indexar(documento Documento) error
+	//Remover tira a publicação do índice
+	Remover(publicacaoID uint64) error
+	//Buscar traz a página de resultados da consulta, do mais para o menos relevante
+	Buscar(consulta Consulta) ([]Resultado, error)
+}
+
+// Documento é o que é indexado de uma publicação
+type Documento struct {
+	PublicacaoID uint64
+	AutorID      uint64
+	Titulo       string
+	Conteudo     string
+	CriadoEm     time.Time
+}
+
+// Consulta representa uma busca, com filtros opcionais e a página desejada
+type Consulta struct {
+	Texto   string
+	AutorID uint64
+	//De e Ate limitam a data de criação, valores zerados não filtram
+	De, Ate time.Time
+	Pagina  int
+	Limite  int
+}
+
+// Resultado é uma publicação encontrada e sua pontuação, que já considera relevância e idade
+type Resultado struct {
+	PublicacaoID uint64
+	Pontuacao    float64
+}
+
+// atual é o índice em memória, fica nil quando a busca é feita direto no banco
+var atual Indice
+
+// UsarIndice passa a manter e consultar o índice recebido em vez do banco
+func UsarIndice(indice Indice) {
+	atual = indice
+}
+
+// IndiceAtual retorna o índice configurado com UsarIndice, nil se a busca é feita pelo banco
+func IndiceAtual() Indice {
+	return atual
+}
+
+// Indexar atualiza o documento no índice configurado, se houver um
+func Indexar(documento Documento) error {
+	if atual == nil {
+		return nil
+	}
+	return atual.Indexar(documento)
+}
+
+// Remover tira a publicação do índice configurado, se houver um
+func Remover(publicacaoID uint64) error {
+	if atual == nil {
+		return nil
+	}
+	return atual.Remover(publicacaoID)
+}
+
+// FatorDeRecencia é o peso da idade de uma publicação na pontuação, 1 para agora e 0.5 depois de MeiaVida
+func FatorDeRecencia(criadoEm time.Time) float64 {
+	idade := time.Since(criadoEm)
+	if idade < 0 {
+		idade = 0
+	}
+	return math.Pow(0.5, float64(idade)/float64(MeiaVida))
+}
@@ -0,0 +1,149 @@
+package busca
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// parâmetros do BM25
+const (
+	k1 = 1.2
+	b  = 0.75
+	//pesoDoTitulo é quantas vezes um termo do título conta em relação a um do conteúdo
+	pesoDoTitulo = 2
+)
+
+// Memoria é um índice invertido em memória com pontuação BM25, feito em go puro
+type Memoria struct {
+	mutex      sync.RWMutex
+	documentos map[uint64]documentoIndexado
+	//ocorrencias guarda, para cada termo, quantas vezes ele aparece em cada publicação
+	ocorrencias  map[string]map[uint64]int
+	tamanhoTotal int
+}
+
+// documentoIndexado é o que o índice guarda de cada documento além das ocorrências
+type documentoIndexado struct {
+	Documento
+	termos  map[string]int
+	tamanho int
+}
+
+// NovaMemoria cria um índice em memória vazio
+func NovaMemoria() *Memoria {
+	return &Memoria{documentos: map[uint64]documentoIndexado{}, ocorrencias: map[string]map[uint64]int{}}
+}
+
+// Indexar adiciona ou substitui o documento. AutorID e CriadoEm zerados mantêm os valores já indexados
+func (memoria *Memoria) Indexar(documento Documento) error {
+	memoria.mutex.Lock()
+	defer memoria.mutex.Unlock()
+	if anterior, existe := memoria.documentos[documento.PublicacaoID]; existe {
+		if documento.AutorID == 0 {
+			documento.AutorID = anterior.AutorID
+		}
+		if documento.CriadoEm.IsZero() {
+			documento.CriadoEm = anterior.CriadoEm
+		}
+		memoria.remover(documento.PublicacaoID)
+	}
+	indexado := documentoIndexado{Documento: documento, termos: map[string]int{}}
+	for _, termo := range Termos(documento.Titulo) {
+		indexado.termos[termo] += pesoDoTitulo
+		indexado.tamanho += pesoDoTitulo
+	}
+	for _, termo := range Termos(documento.Conteudo) {
+		indexado.termos[termo]++
+		indexado.tamanho++
+	}
+	for termo, frequencia := range indexado.termos {
+		if memoria.ocorrencias[termo] == nil {
+			memoria.ocorrencias[termo] = map[uint64]int{}
+		}
+		memoria.ocorrencias[termo][documento.PublicacaoID] = frequencia
+	}
+	memoria.documentos[documento.PublicacaoID] = indexado
+	memoria.tamanhoTotal += indexado.tamanho
+	return nil
+}
+
+// Remover tira a publicação do índice
+func (memoria *Memoria) Remover(publicacaoID uint64) error {
+	memoria.mutex.Lock()
+	defer memoria.mutex.Unlock()
+	memoria.remover(publicacaoID)
+	return nil
+}
+
+// remover faz a remoção, quem chama precisa estar com o mutex travado
+func (memoria *Memoria) remover(publicacaoID uint64) {
+	indexado, existe := memoria.documentos[publicacaoID]
+	if !existe {
+		return
+	}
+	for termo := range indexado.termos {
+		delete(memoria.ocorrencias[termo], publicacaoID)
+		if len(memoria.ocorrencias[termo]) == 0 {
+			delete(memoria.ocorrencias, termo)
+		}
+	}
+	memoria.tamanhoTotal -= indexado.tamanho
+	delete(memoria.documentos, publicacaoID)
+}
+
+// Buscar pontua com BM25 todo documento que tenha algum termo da consulta, aplica os filtros e o fator de recência
+func (memoria *Memoria) Buscar(consulta Consulta) ([]Resultado, error) {
+	memoria.mutex.RLock()
+	defer memoria.mutex.RUnlock()
+	if len(memoria.documentos) == 0 {
+		return nil, nil
+	}
+	quantidade := float64(len(memoria.documentos))
+	tamanhoMedio := float64(memoria.tamanhoTotal) / quantidade
+	pontuacoes := map[uint64]float64{}
+	vistos := map[string]bool{}
+	for _, termo := range Termos(consulta.Texto) {
+		if vistos[termo] {
+			continue
+		}
+		vistos[termo] = true
+		ocorrencias := memoria.ocorrencias[termo]
+		idf := math.Log(1 + (quantidade-float64(len(ocorrencias))+0.5)/(float64(len(ocorrencias))+0.5))
+		for publicacaoID, frequencia := range ocorrencias {
+			documento := memoria.documentos[publicacaoID]
+			if !passaNosFiltros(documento.Documento, consulta) {
+				continue
+			}
+			tf := float64(frequencia)
+			pontuacoes[publicacaoID] += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(documento.tamanho)/tamanhoMedio))
+		}
+	}
+	resultados := make([]Resultado, 0, len(pontuacoes))
+	for publicacaoID, pontuacao := range pontuacoes {
+		resultados = append(resultados, Resultado{publicacaoID, pontuacao * FatorDeRecencia(memoria.documentos[publicacaoID].CriadoEm)})
+	}
+	sort.Slice(resultados, func(i, j int) bool {
+		if resultados[i].Pontuacao != resultados[j].Pontuacao {
+			return resultados[i].Pontuacao > resultados[j].Pontuacao
+		}
+		return resultados[i].PublicacaoID > resultados[j].PublicacaoID
+	})
+	inicio := min(len(resultados), (consulta.Pagina-1)*consulta.Limite)
+	fim := min(len(resultados), inicio+consulta.Limite)
+	return resultados[inicio:fim], nil
+}
+
+// passaNosFiltros verifica autor e período da consulta
+func passaNosFiltros(documento Documento, consulta Consulta) bool {
+	if consulta.AutorID != 0 && documento.AutorID != consulta.AutorID {
+		return false
+	}
+	if !consulta.De.IsZero() && documento.CriadoEm.Before(consulta.De) {
+		return false
+	}
+	if !consulta.Ate.IsZero() && !documento.CriadoEm.Before(consulta.Ate) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package busca
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// tamanhoDoTrecho é quantos caracteres do conteúdo aparecem no trecho de um resultado
+const tamanhoDoTrecho = 120
+
+// semAcento troca as letras acentuadas mais comuns pela letra sem acento, sempre uma runa por outra
+var semAcento = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// token é uma palavra do texto, com a posição em runas dela no texto original
+type token struct {
+	termo       string
+	inicio, fim int
+}
+
+// Normalizar deixa o texto em minúsculas e sem acentos, para comparações que ignoram os dois
+func Normalizar(texto string) string {
+	return semAcento.Replace(strings.ToLower(texto))
+}
+
+// Termos quebra o texto em palavras normalizadas
+func Termos(texto string) []string {
+	var termos []string
+	for _, t := range tokenizar([]rune(texto)) {
+		termos = append(termos, t.termo)
+	}
+	return termos
+}
+
+// tokenizar separa as palavras (sequências de letras e números) do texto
+func tokenizar(runas []rune) []token {
+	var tokens []token
+	inicio := -1
+	for i := 0; i <= len(runas); i++ {
+		if i < len(runas) && (unicode.IsLetter(runas[i]) || unicode.IsDigit(runas[i])) {
+			if inicio < 0 {
+				inicio = i
+			}
+			continue
+		}
+		if inicio >= 0 {
+			tokens = append(tokens, token{Normalizar(string(runas[inicio:i])), inicio, i})
+			inicio = -1
+		}
+	}
+	return tokens
+}
+
+// Trecho recorta o pedaço do texto em volta do primeiro termo encontrado e destaca os termos com <mark>.
+// O resto do texto é escapado, já que o trecho vai ser exibido como html
+func Trecho(texto string, termos []string) string {
+	procurados := make(map[string]bool, len(termos))
+	for _, termo := range termos {
+		procurados[termo] = true
+	}
+	runas := []rune(texto)
+	tokens := tokenizar(runas)
+	inicio, fim := 0, len(runas)
+	if len(runas) > tamanhoDoTrecho {
+		centro := 0
+		for _, t := range tokens {
+			if procurados[t.termo] {
+				centro = t.inicio
+				break
+			}
+		}
+		fim = min(len(runas), max(0, centro-tamanhoDoTrecho/3)+tamanhoDoTrecho)
+		inicio = fim - tamanhoDoTrecho
+	}
+	var trecho strings.Builder
+	if inicio > 0 {
+		trecho.WriteString("…")
+	}
+	cursor := inicio
+	for _, t := range tokens {
+		if t.inicio < inicio || t.fim > fim || !procurados[t.termo] {
+			continue
+		}
+		trecho.WriteString(html.EscapeString(string(runas[cursor:t.inicio])))
+		trecho.WriteString("<mark>" + html.EscapeString(string(runas[t.inicio:t.fim])) + "</mark>")
+		cursor = t.fim
+	}
+	trecho.WriteString(html.EscapeString(string(runas[cursor:fim])))
+	if fim < len(runas) {
+		trecho.WriteString("…")
+	}
+	return trecho.String()
+}
+
+// ContemFrase diz se as palavras da frase aparecem seguidas no texto, ignorando maiúsculas, acentos e pontuação.
+// Frase sem nenhuma palavra não aparece em texto nenhum
+func ContemFrase(texto, frase string) bool {
+	procurados := Termos(frase)
+	if len(procurados) == 0 {
+		return false
+	}
+	termos := Termos(texto)
+	for i := 0; i+len(procurados) <= len(termos); i++ {
+		encontrou := true
+		for j, procurado := range procurados {
+			if termos[i+j] != procurado {
+				encontrou = false
+				break
+			}
+		}
+		if encontrou {
+			return true
+		}
+	}
+	return false
+}
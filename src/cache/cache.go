@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"api/src/config"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Cache é o que a aplicação precisa de um armazenamento chave/valor para evitar ir no banco a cada requisição
+type Cache interface {
+	//Buscar traz o valor salvo em chave, o bool é falso se a chave não existir ou tiver expirado
+	Buscar(chave string) ([]byte, bool, error)
+	//Salvar guarda valor em chave por ttl, ou sem expirar se ttl for zero ou negativo
+	Salvar(chave string, valor []byte, ttl time.Duration) error
+	//Remover apaga as chaves, chaves inexistentes são ignoradas
+	Remover(chaves ...string) error
+	//Incrementar soma 1 no contador guardado em chave e retorna o novo valor. Contadores não expiram
+	Incrementar(chave string) (int64, error)
+}
+
+// Metricas representa os acertos e falhas do cache desde que a api subiu
+type Metricas struct {
+	Backend      string  `json:"backend"`
+	Acertos      uint64  `json:"acertos"`
+	Falhas       uint64  `json:"falhas"`
+	TaxaDeAcerto float64 `json:"taxaDeAcerto"`
+}
+
+var (
+	//atual é o cache usado pela aplicação, começa desligado até Configurar ser chamado
+	atual Cache = nenhum{}
+	//backend é o nome do cache configurado
+	backend = "nenhum"
+	//TTL é por quanto tempo os valores ficam no cache
+	TTL = time.Minute
+	//contagem de acertos e falhas das buscas
+	acertos, falhas atomic.Uint64
+)
+
+// Configurar escolhe o cache de acordo com as variáveis de ambiente carregadas em config
+func Configurar() error {
+	TTL = config.CacheTTL
+	switch config.CacheBackend {
+	case "", "memoria":
+		atual, backend = NovaMemoria(config.CacheCapacidade), "memoria"
+	case "redis":
+		redis := NovoRedis(config.RedisEndereco)
+		//testando a conexão logo ao subir pra não descobrir o problema só na primeira requisição
+		if _, _, erro := redis.Buscar("ping"); erro != nil {
+			return erro
+		}
+		atual, backend = redis, "redis"
+	case "nenhum":
+		atual, backend = nenhum{}, "nenhum"
+	default:
+		return fmt.Errorf("cache %q desconhecido, use memoria, redis ou nenhum", config.CacheBackend)
+	}
+	return nil
+}
+
+// Buscar traz o valor de chave do cache configurado contabilizando acerto ou falha
+func Buscar(chave string) ([]byte, bool, error) {
+	valor, encontrado, erro := atual.Buscar(chave)
+	if erro == nil && encontrado {
+		acertos.Add(1)
+	} else {
+		falhas.Add(1)
+	}
+	return valor, encontrado, erro
+}
+
+// Contador lê o valor atual de um contador criado com Incrementar, sem contar nas métricas. Retorna 0 se ele não existir
+func Contador(chave string) (int64, error) {
+	valor, encontrado, erro := atual.Buscar(chave)
+	if erro != nil || !encontrado {
+		return 0, erro
+	}
+	return strconv.ParseInt(string(valor), 10, 64)
+}
+
+// Salvar guarda valor em chave no cache configurado usando o TTL padrão
+func Salvar(chave string, valor []byte) error {
+	return atual.Salvar(chave, valor, TTL)
+}
+
+// Remover apaga chaves do cache configurado
+func Remover(chaves ...string) error {
+	return atual.Remover(chaves...)
+}
+
+// Incrementar soma 1 no contador de chave do cache configurado
+func Incrementar(chave string) (int64, error) {
+	return atual.Incrementar(chave)
+}
+
+// BuscarMetricas retorna os acertos e falhas contabilizados até agora
+func BuscarMetricas() Metricas {
+	metricas := Metricas{Backend: backend, Acertos: acertos.Load(), Falhas: falhas.Load()}
+	if total := metricas.Acertos + metricas.Falhas; total > 0 {
+		metricas.TaxaDeAcerto = float64(metricas.Acertos) / float64(total)
+	}
+	return metricas
+}
+
+// nenhum é um cache que não guarda nada, usado quando o cache está desligado
+type nenhum struct{}
+
+func (nenhum) Buscar(string) ([]byte, bool, error)        { return nil, false, nil }
+func (nenhum) Salvar(string, []byte, time.Duration) error { return nil }
+func (nenhum) Remover(...string) error                    { return nil }
+func (nenhum) Incrementar(string) (int64, error)          { return 0, nil }
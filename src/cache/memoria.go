@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Memoria é um cache LRU com expiração que fica na memória do próprio processo
+type Memoria struct {
+	mutex      sync.Mutex
+	capacidade int
+	itens      map[string]*list.Element
+	//ordem guarda os itens do mais recente (frente) para o menos recente (fundo)
+	ordem *list.List
+	//contadores ficam separados dos itens pra nunca serem descartados pelo LRU
+	contadores map[string]int64
+}
+
+// itemDaMemoria é o que fica guardado em cada elemento da lista de Memoria
+type itemDaMemoria struct {
+	chave    string
+	valor    []byte
+	expiraEm time.Time
+}
+
+// NovaMemoria cria um cache em memória que guarda no máximo capacidade itens
+func NovaMemoria(capacidade int) *Memoria {
+	if capacidade <= 0 {
+		capacidade = 10000
+	}
+	return &Memoria{
+		capacidade: capacidade,
+		itens:      make(map[string]*list.Element),
+		ordem:      list.New(),
+		contadores: make(map[string]int64),
+	}
+}
+
+// Buscar traz o valor de chave se ele existir e não tiver expirado
+func (memoria *Memoria) Buscar(chave string) ([]byte, bool, error) {
+	memoria.mutex.Lock()
+	defer memoria.mutex.Unlock()
+	elemento, existe := memoria.itens[chave]
+	if !existe {
+		//igual ao redis, um contador também pode ser lido como valor
+		if contador, existe := memoria.contadores[chave]; existe {
+			return []byte(strconv.FormatInt(contador, 10)), true, nil
+		}
+		return nil, false, nil
+	}
+	item := elemento.Value.(*itemDaMemoria)
+	if !item.expiraEm.IsZero() && time.Now().After(item.expiraEm) {
+		memoria.ordem.Remove(elemento)
+		delete(memoria.itens, chave)
+		return nil, false, nil
+	}
+	//item usado agora vai pra frente da lista
+	memoria.ordem.MoveToFront(elemento)
+	return item.valor, true, nil
+}
+
+// Salvar guarda valor em chave, descartando o item usado há mais tempo se o cache estiver cheio
+func (memoria *Memoria) Salvar(chave string, valor []byte, ttl time.Duration) error {
+	memoria.mutex.Lock()
+	defer memoria.mutex.Unlock()
+	//igual ao redis, sem ttl o valor não expira
+	var expiraEm time.Time
+	if ttl > 0 {
+		expiraEm = time.Now().Add(ttl)
+	}
+	if elemento, existe := memoria.itens[chave]; existe {
+		item := elemento.Value.(*itemDaMemoria)
+		item.valor, item.expiraEm = valor, expiraEm
+		memoria.ordem.MoveToFront(elemento)
+		return nil
+	}
+	memoria.itens[chave] = memoria.ordem.PushFront(&itemDaMemoria{chave, valor, expiraEm})
+	for memoria.ordem.Len() > memoria.capacidade {
+		maisAntigo := memoria.ordem.Back()
+		memoria.ordem.Remove(maisAntigo)
+		delete(memoria.itens, maisAntigo.Value.(*itemDaMemoria).chave)
+	}
+	return nil
+}
+
+// Remover apaga as chaves do cache
+func (memoria *Memoria) Remover(chaves ...string) error {
+	memoria.mutex.Lock()
+	defer memoria.mutex.Unlock()
+	for _, chave := range chaves {
+		if elemento, existe := memoria.itens[chave]; existe {
+			memoria.ordem.Remove(elemento)
+			delete(memoria.itens, chave)
+		}
+		delete(memoria.contadores, chave)
+	}
+	return nil
+}
+
+// Incrementar soma 1 no contador de chave
+func (memoria *Memoria) Incrementar(chave string) (int64, error) {
+	memoria.mutex.Lock()
+	defer memoria.mutex.Unlock()
+	memoria.contadores[chave]++
+	return memoria.contadores[chave], nil
+}
@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// conexoesOciosasDoRedis é quantas conexões com o redis ficam abertas esperando a próxima requisição
+const conexoesOciosasDoRedis = 8
+
+// Redis é um cache que fala o protocolo do redis (RESP) com um servidor, local ou não
+type Redis struct {
+	endereco string
+	ociosas  chan *conexaoRedis
+}
+
+// conexaoRedis é uma conexão com o servidor e o leitor com buffer das respostas dela
+type conexaoRedis struct {
+	net.Conn
+	leitor *bufio.Reader
+}
+
+// erroRedis é um erro retornado pelo próprio servidor (resposta que começa com -)
+type erroRedis string
+
+func (erro erroRedis) Error() string {
+	return "redis: " + string(erro)
+}
+
+// NovoRedis cria um cache que usa o servidor redis em endereco (host:porta)
+func NovoRedis(endereco string) *Redis {
+	return &Redis{endereco: endereco, ociosas: make(chan *conexaoRedis, conexoesOciosasDoRedis)}
+}
+
+// Buscar traz o valor de chave com GET
+func (redis *Redis) Buscar(chave string) ([]byte, bool, error) {
+	resposta, erro := redis.comando("GET", chave)
+	if erro != nil || resposta == nil {
+		return nil, false, erro
+	}
+	return resposta.([]byte), true, nil
+}
+
+// Salvar guarda valor em chave com SET e expiração em milissegundos. Com ttl zero ou negativo o valor não expira,
+// já que o redis recusa PX 0
+func (redis *Redis) Salvar(chave string, valor []byte, ttl time.Duration) error {
+	argumentos := []string{chave, string(valor)}
+	if ttl > 0 {
+		//menos de 1ms também viraria PX 0
+		argumentos = append(argumentos, "PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+	}
+	_, erro := redis.comando("SET", argumentos...)
+	return erro
+}
+
+// Remover apaga as chaves com DEL
+func (redis *Redis) Remover(chaves ...string) error {
+	if len(chaves) == 0 {
+		return nil
+	}
+	_, erro := redis.comando("DEL", chaves...)
+	return erro
+}
+
+// Incrementar soma 1 no contador de chave com INCR
+func (redis *Redis) Incrementar(chave string) (int64, error) {
+	resposta, erro := redis.comando("INCR", chave)
+	if erro != nil {
+		return 0, erro
+	}
+	valor, ok := resposta.(int64)
+	if !ok {
+		return 0, errors.New("redis: resposta inesperada para INCR")
+	}
+	return valor, nil
+}
+
+// comando envia um comando ao servidor e lê a resposta
+func (redis *Redis) comando(nome string, argumentos ...string) (interface{}, error) {
+	conexao, erro := redis.conexao()
+	if erro != nil {
+		return nil, erro
+	}
+	conexao.SetDeadline(time.Now().Add(2 * time.Second))
+	//todo comando vai como um array de bulk strings: *<n>\r\n$<tamanho>\r\n<arg>\r\n...
+	requisicao := fmt.Sprintf("*%d\r\n$%d\r\n%s\r\n", len(argumentos)+1, len(nome), nome)
+	for _, argumento := range argumentos {
+		requisicao += fmt.Sprintf("$%d\r\n%s\r\n", len(argumento), argumento)
+	}
+	if _, erro = io.WriteString(conexao, requisicao); erro != nil {
+		conexao.Close()
+		return nil, erro
+	}
+	resposta, erro := lerResposta(conexao.leitor)
+	if erro != nil {
+		//erro do servidor não estraga a conexão, já erro de rede sim
+		if _, doServidor := erro.(erroRedis); !doServidor {
+			conexao.Close()
+			return nil, erro
+		}
+	}
+	redis.devolver(conexao)
+	return resposta, erro
+}
+
+// conexao pega uma conexão ociosa ou abre uma nova
+func (redis *Redis) conexao() (*conexaoRedis, error) {
+	select {
+	case conexao := <-redis.ociosas:
+		return conexao, nil
+	default:
+	}
+	conexao, erro := net.DialTimeout("tcp", redis.endereco, 2*time.Second)
+	if erro != nil {
+		return nil, erro
+	}
+	return &conexaoRedis{conexao, bufio.NewReader(conexao)}, nil
+}
+
+// devolver guarda a conexão para reuso, fechando se já tiver conexões ociosas demais
+func (redis *Redis) devolver(conexao *conexaoRedis) {
+	select {
+	case redis.ociosas <- conexao:
+	default:
+		conexao.Close()
+	}
+}
+
+// lerResposta interpreta uma resposta RESP: string simples, erro, inteiro, bulk string ou array
+func lerResposta(leitor *bufio.Reader) (interface{}, error) {
+	linha, erro := leitor.ReadString('\n')
+	if erro != nil {
+		return nil, erro
+	}
+	if len(linha) < 3 {
+		return nil, errors.New("redis: resposta mal formada")
+	}
+	tipo, conteudo := linha[0], linha[1:len(linha)-2]
+	switch tipo {
+	case '+':
+		return conteudo, nil
+	case '-':
+		return nil, erroRedis(conteudo)
+	case ':':
+		return strconv.ParseInt(conteudo, 10, 64)
+	case '$':
+		tamanho, erro := strconv.Atoi(conteudo)
+		if erro != nil {
+			return nil, erro
+		}
+		//$-1 é o nil do redis, chave não existe
+		if tamanho < 0 {
+			return nil, nil
+		}
+		dados := make([]byte, tamanho+2)
+		if _, erro = io.ReadFull(leitor, dados); erro != nil {
+			return nil, erro
+		}
+		return dados[:tamanho], nil
+	case '*':
+		quantidade, erro := strconv.Atoi(conteudo)
+		if erro != nil || quantidade < 0 {
+			return nil, erro
+		}
+		itens := make([]interface{}, quantidade)
+		for i := range itens {
+			if itens[i], erro = lerResposta(leitor); erro != nil {
+				return nil, erro
+			}
+		}
+		return itens, nil
+	}
+	return nil, fmt.Errorf("redis: tipo de resposta desconhecido %q", tipo)
+}
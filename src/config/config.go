@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,10 +14,49 @@ import (
 var (
 	//Conexao é a string de conexao c mysql
 	Conexao = ""
+	//ConexoesReplicas são as strings de conexão das réplicas de leitura, pode ficar vazio
+	ConexoesReplicas []string
+	//JanelaLeituraNoPrimario é por quanto tempo um usuário que acabou de escrever continua lendo do primário
+	JanelaLeituraNoPrimario = 5 * time.Second
 	//Porta onde api vai estar rodando
 	Porta = 0
 	//SecretKey é chave para assinar o token
 	SecretKey []byte
+	//ExigirIfMatch faz as atualizações sem o cabeçalho If-Match serem recusadas
+	ExigirIfMatch = false
+	//CacheBackend é o cache usado (memoria, redis ou nenhum)
+	CacheBackend = ""
+	//CacheCapacidade é quantos itens o cache em memória guarda
+	CacheCapacidade = 0
+	//CacheTTL é por quanto tempo um valor fica no cache
+	CacheTTL = time.Minute
+	//RedisEndereco é o host:porta do redis quando CacheBackend é redis
+	RedisEndereco = ""
+	//BuscaBackend é onde a busca de texto é feita (mysql ou memoria)
+	BuscaBackend = ""
+	//RetencaoDePublicacoesDeletadas é por quanto tempo uma publicação deletada fica guardada para moderação
+	RetencaoDePublicacoesDeletadas = 30 * 24 * time.Hour
+	//ProfundidadeMaximaDeComentarios é até quantos níveis de respostas a respostas são aceitos
+	ProfundidadeMaximaDeComentarios = 5
+	//JanelaDeHashtagsEmAlta é o tamanho da janela em que o uso das hashtags é comparado com a janela anterior
+	JanelaDeHashtagsEmAlta = time.Hour
+	//ArmazenamentoBackend é onde os arquivos enviados ficam (local ou s3)
+	ArmazenamentoBackend = ""
+	//ArmazenamentoDiretorio é a pasta dos arquivos quando ArmazenamentoBackend é local
+	ArmazenamentoDiretorio = ""
+	//S3Endpoint é a url do serviço compatível com S3, como https://s3.amazonaws.com ou o endereço de um MinIO
+	S3Endpoint = ""
+	//S3Regiao é a região usada na assinatura das requisições ao S3
+	S3Regiao = ""
+	//S3Bucket é o bucket onde os arquivos são guardados
+	S3Bucket = ""
+	//S3ChaveDeAcesso e S3Segredo são as credenciais do S3
+	S3ChaveDeAcesso = ""
+	S3Segredo       = ""
+	//TamanhoMaximoDeImagem é quantos bytes uma imagem enviada pode ter
+	TamanhoMaximoDeImagem int64 = 5 << 20
+	//DimensaoMaximaDeImagem é quantos pixels de largura ou altura uma imagem enviada pode ter
+	DimensaoMaximaDeImagem = 4096
 )
 
 // Carregar vai inicializar as variáveis de ambiente
@@ -37,5 +78,88 @@ func Carregar() {
 		os.Getenv("DB_NOME"),
 	)
 
+	//réplicas vêm separadas por vírgula, já no formato de dsn do driver do mysql
+	ConexoesReplicas = nil
+	for _, replica := range strings.Split(os.Getenv("DB_REPLICAS"), ",") {
+		if replica = strings.TrimSpace(replica); replica != "" {
+			ConexoesReplicas = append(ConexoesReplicas, replica)
+		}
+	}
+	milissegundos, erro := strconv.Atoi(os.Getenv("DB_JANELA_PRIMARIO_MS"))
+	if erro != nil {
+		milissegundos = 5000
+	}
+	JanelaLeituraNoPrimario = time.Duration(milissegundos) * time.Millisecond
+
 	SecretKey = []byte(os.Getenv("SECRET_KEY"))
+
+	ExigirIfMatch, erro = strconv.ParseBool(os.Getenv("EXIGIR_IF_MATCH"))
+	if erro != nil {
+		ExigirIfMatch = false
+	}
+
+	CacheBackend = os.Getenv("CACHE_BACKEND")
+	CacheCapacidade, erro = strconv.Atoi(os.Getenv("CACHE_CAPACIDADE"))
+	if erro != nil {
+		CacheCapacidade = 10000
+	}
+	segundos, erro := strconv.Atoi(os.Getenv("CACHE_TTL_SEGUNDOS"))
+	if erro != nil {
+		segundos = 60
+	}
+	CacheTTL = time.Duration(segundos) * time.Second
+	RedisEndereco = os.Getenv("REDIS_ENDERECO")
+	if RedisEndereco == "" {
+		RedisEndereco = "localhost:6379"
+	}
+
+	BuscaBackend = os.Getenv("BUSCA_BACKEND")
+	if BuscaBackend == "" {
+		BuscaBackend = "mysql"
+	}
+
+	dias, erro := strconv.Atoi(os.Getenv("PUBLICACOES_RETENCAO_DIAS"))
+	if erro != nil {
+		dias = 30
+	}
+	RetencaoDePublicacoesDeletadas = time.Duration(dias) * 24 * time.Hour
+
+	//o caminho de um comentário guarda 11 caracteres por nível num varchar(255), então 20 é o limite
+	ProfundidadeMaximaDeComentarios, erro = strconv.Atoi(os.Getenv("COMENTARIOS_PROFUNDIDADE_MAXIMA"))
+	if erro != nil || ProfundidadeMaximaDeComentarios < 0 || ProfundidadeMaximaDeComentarios > 20 {
+		ProfundidadeMaximaDeComentarios = 5
+	}
+
+	minutos, erro := strconv.Atoi(os.Getenv("HASHTAGS_JANELA_MINUTOS"))
+	if erro != nil || minutos <= 0 {
+		minutos = 60
+	}
+	JanelaDeHashtagsEmAlta = time.Duration(minutos) * time.Minute
+
+	ArmazenamentoBackend = os.Getenv("ARMAZENAMENTO_BACKEND")
+	if ArmazenamentoBackend == "" {
+		ArmazenamentoBackend = "local"
+	}
+	ArmazenamentoDiretorio = os.Getenv("ARMAZENAMENTO_DIRETORIO")
+	if ArmazenamentoDiretorio == "" {
+		ArmazenamentoDiretorio = "arquivos"
+	}
+	S3Endpoint = os.Getenv("S3_ENDPOINT")
+	S3Regiao = os.Getenv("S3_REGIAO")
+	if S3Regiao == "" {
+		S3Regiao = "us-east-1"
+	}
+	S3Bucket = os.Getenv("S3_BUCKET")
+	S3ChaveDeAcesso = os.Getenv("S3_CHAVE_DE_ACESSO")
+	S3Segredo = os.Getenv("S3_SEGREDO")
+
+	megabytes, erro := strconv.Atoi(os.Getenv("MIDIA_TAMANHO_MAXIMO_MB"))
+	if erro != nil || megabytes <= 0 {
+		megabytes = 5
+	}
+	TamanhoMaximoDeImagem = int64(megabytes) << 20
+	DimensaoMaximaDeImagem, erro = strconv.Atoi(os.Getenv("MIDIA_DIMENSAO_MAXIMA"))
+	if erro != nil || DimensaoMaximaDeImagem <= 0 {
+		DimensaoMaximaDeImagem = 4096
+	}
 }
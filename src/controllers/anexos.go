@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"api/src/armazenamento"
+	"api/src/autenticacao"
+	"api/src/banco"
+	"api/src/config"
+	"api/src/midia"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// margemDoFormulario é o que o corpo de um envio pode ter além da imagem, com as fronteiras e os outros campos
+const margemDoFormulario = 64 << 10
+
+// erroAnexoNaoEncontrado é retornado quando o anexo não existe ou o usuário logado não pode vê-lo
+var erroAnexoNaoEncontrado = errors.New("anexo não encontrado")
+
+// extensoes dá a extensão usada nas chaves do armazenamento para cada tipo de imagem
+var extensoes = map[string]string{
+	midia.TipoJPEG: ".jpg",
+	midia.TipoPNG:  ".png",
+	midia.TipoGIF:  ".gif",
+}
+
+// CriarAnexo recebe uma imagem em multipart/form-data (campo imagem, e textoAlternativo opcional), limpa os metadados,
+// gera a miniatura e guarda as duas. O anexo fica com o usuário logado até ele usar o id numa publicação ou no perfil
+func CriarAnexo(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//lendo a imagem sem deixar o corpo passar do tamanho máximo
+	r.Body = http.MaxBytesReader(w, r.Body, config.TamanhoMaximoDeImagem+margemDoFormulario)
+	if erro = r.ParseMultipartForm(config.TamanhoMaximoDeImagem); erro != nil {
+		var erroTamanho *http.MaxBytesError
+		if errors.As(erro, &erroTamanho) {
+			respostas.Erro(w, http.StatusRequestEntityTooLarge, erroImagemPesadaDemais())
+			return
+		}
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	arquivo, _, erro := r.FormFile("imagem")
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("o campo imagem é obrigatório"))
+		return
+	}
+	defer arquivo.Close()
+	conteudo, erro := io.ReadAll(io.LimitReader(arquivo, config.TamanhoMaximoDeImagem+1))
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+	if int64(len(conteudo)) > config.TamanhoMaximoDeImagem {
+		respostas.Erro(w, http.StatusRequestEntityTooLarge, erroImagemPesadaDemais())
+		return
+	}
+	//fazendo verificações
+	anexo := modelos.Anexo{DonoID: usuarioID, TextoAlternativo: r.FormValue("textoAlternativo")}
+	if erro = anexo.Preparar(); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	imagem, erro := midia.Processar(conteudo, config.DimensaoMaximaDeImagem)
+	if erro == midia.ErroTipoNaoPermitido {
+		respostas.Erro(w, http.StatusUnsupportedMediaType, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+	anexo.Tipo, anexo.Largura, anexo.Altura, anexo.Tamanho = imagem.Tipo, imagem.Largura, imagem.Altura, int64(len(imagem.Conteudo))
+	//guardando os arquivos com um nome aleatório, para ninguém adivinhar o endereço
+	nome, erro := nomeAleatorio()
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	anexo.Chave = fmt.Sprintf("anexos/%d/%s%s", usuarioID, nome, extensoes[imagem.Tipo])
+	anexo.ChaveMiniatura = fmt.Sprintf("anexos/%d/%s-miniatura%s", usuarioID, nome, extensoes[imagem.TipoMiniatura])
+	if erro = armazenamento.Salvar(anexo.Chave, imagem.Conteudo, imagem.Tipo); erro == nil {
+		erro = armazenamento.Salvar(anexo.ChaveMiniatura, imagem.Miniatura, imagem.TipoMiniatura)
+	}
+	if erro != nil {
+		removerArquivos(anexo)
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		removerArquivos(anexo)
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	repositorio := repositorios.NovoRepositorioDeAnexos(db)
+	anexoID, erro := repositorio.Criar(anexo)
+	if erro != nil {
+		removerArquivos(anexo)
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	anexo, erro = repositorio.BuscarPorID(anexoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusCreated, anexo)
+}
+
+// AtualizarAnexo troca o texto alternativo de um anexo do usuário logado, mesmo que ele já esteja numa publicação
+func AtualizarAnexo(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	anexoID, erro := strconv.ParseUint(parametros["anexoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//lendo requisição
+	corpoRequest, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+	//passando pra struct
+	var dados modelos.Anexo
+	if erro = json.Unmarshal(corpoRequest, &dados); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//fazendo verificações
+	if erro = dados.Preparar(); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	repositorio := repositorios.NovoRepositorioDeAnexos(db)
+	anexo, erro := repositorio.BuscarPorID(anexoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if anexo.ID == 0 || anexo.DonoID != usuarioID {
+		respostas.Erro(w, http.StatusNotFound, erroAnexoNaoEncontrado)
+		return
+	}
+	anexo.TextoAlternativo = dados.TextoAlternativo
+	if erro = repositorio.AtualizarTextoAlternativo(anexo); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, anexo)
+}
+
+// BuscarArquivoDoAnexo entrega a imagem de um anexo que o usuário logado pode ver
+func BuscarArquivoDoAnexo(w http.ResponseWriter, r *http.Request) {
+	servirAnexo(w, r, false)
+}
+
+// BuscarMiniaturaDoAnexo entrega a miniatura de um anexo que o usuário logado pode ver
+func BuscarMiniaturaDoAnexo(w http.ResponseWriter, r *http.Request) {
+	servirAnexo(w, r, true)
+}
+
+// servirAnexo entrega a imagem ou a miniatura de um anexo que o usuário logado pode ver
+func servirAnexo(w http.ResponseWriter, r *http.Request, miniatura bool) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	anexoID, erro := strconv.ParseUint(parametros["anexoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	anexo, erro := repositorios.NovoRepositorioDeAnexos(db).BuscarPorID(anexoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	visivel, erro := anexoVisivel(db, anexo, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if !visivel {
+		respostas.Erro(w, http.StatusNotFound, erroAnexoNaoEncontrado)
+		return
+	}
+	chave := anexo.Chave
+	if miniatura {
+		chave = anexo.ChaveMiniatura
+	}
+	arquivo, tipo, erro := armazenamento.Abrir(chave)
+	if erro == armazenamento.ErroNaoEncontrado {
+		respostas.Erro(w, http.StatusNotFound, erroAnexoNaoEncontrado)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer arquivo.Close()
+	//o conteúdo de uma chave nunca muda, mas quem pode ver sim, então só o navegador guarda
+	w.Header().Set("Content-Type", tipo)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	if _, erro = io.Copy(w, arquivo); erro != nil {
+		log.Printf("erro ao enviar o anexo %d: %v", anexo.ID, erro)
+	}
+}
+
+// anexoVisivel diz se usuarioID pode ver o anexo. Um anexo numa publicação pode ser visto por quem vê a publicação,
+// o avatar e o banner por quem não tem bloqueio com o dono, e um que ainda não foi usado só pelo dono
+func anexoVisivel(db *sql.DB, anexo modelos.Anexo, usuarioID uint64) (bool, error) {
+	if anexo.ID == 0 {
+		return false, nil
+	}
+	if anexo.PublicacaoID != nil {
+		publicacao, erro := repositorios.NovoRepositorioDePublicacoes(db).BuscarPorID(*anexo.PublicacaoID, usuarioID)
+		return publicacao.ID != 0, erro
+	}
+	if anexo.DonoID == usuarioID {
+		return true, nil
+	}
+	noPerfil, erro := repositorios.NovoRepositorioDeAnexos(db).UsadoNoPerfil(anexo.ID)
+	if erro != nil || !noPerfil {
+		return false, erro
+	}
+	bloqueado, erro := repositorios.NovoRepositorioDeUsuarios(db).Bloqueado(usuarioID, anexo.DonoID)
+	return !bloqueado, erro
+}
+
+// erroImagemPesadaDemais diz o tamanho máximo configurado para as imagens
+func erroImagemPesadaDemais() error {
+	return fmt.Errorf("a imagem pode ter no máximo %d MB", config.TamanhoMaximoDeImagem>>20)
+}
+
+// nomeAleatorio gera 16 bytes aleatórios em hexadecimal
+func nomeAleatorio() (string, error) {
+	bytes := make([]byte, 16)
+	if _, erro := rand.Read(bytes); erro != nil {
+		return "", erro
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// removerArquivos apaga do armazenamento os arquivos de um anexo que não chegou a ser salvo no banco
+func removerArquivos(anexo modelos.Anexo) {
+	if erro := armazenamento.Remover(anexo.Chave, anexo.ChaveMiniatura); erro != nil {
+		log.Printf("erro ao remover os arquivos de %s: %v", anexo.Chave, erro)
+	}
+}
@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/banco"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// erroAudienciaNaoEncontrada é retornado quando a audiência não existe ou é de outro usuário
+var erroAudienciaNaoEncontrada = errors.New("audiência não encontrada")
+
+// CriarAudiencia cria uma lista de usuários para o usuário logado publicar só para eles
+func CriarAudiencia(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//lendo requisição
+	corpoRequest, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+	//passando pra struct
+	var audiencia modelos.Audiencia
+	if erro = json.Unmarshal(corpoRequest, &audiencia); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//fazendo verificações
+	if erro = audiencia.Preparar(usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//criando a audiência e os membros juntos
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		audiencia.ID, erro = transacao.Audiencias.Criar(usuarioID, audiencia)
+		return erro
+	})
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	audiencia, erro = repositorios.NovoRepositorioDeAudiencias(db).BuscarPorID(audiencia.ID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusCreated, audiencia)
+}
+
+// BuscarAudiencias traz as audiências do usuário logado
+func BuscarAudiencias(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	audiencias, erro := repositorios.NovoRepositorioDeAudiencias(db).Buscar(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, audiencias)
+}
+
+// BuscarAudiencia traz uma audiência do usuário logado com os membros dela
+func BuscarAudiencia(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	audienciaID, erro := strconv.ParseUint(parametros["audienciaId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	audiencia, erro := repositorios.NovoRepositorioDeAudiencias(db).BuscarPorID(audienciaID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if audiencia.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, erroAudienciaNaoEncontrada)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, audiencia)
+}
+
+// AtualizarAudiencia troca o nome e os membros de uma audiência do usuário logado
+func AtualizarAudiencia(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	audienciaID, erro := strconv.ParseUint(parametros["audienciaId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//lendo requisição
+	corpoRequest, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+	//passando pra struct
+	var audiencia modelos.Audiencia
+	if erro = json.Unmarshal(corpoRequest, &audiencia); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//fazendo verificações
+	if erro = audiencia.Preparar(usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//conferindo o dono e trocando nome e membros juntos
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		salva, erro := transacao.Audiencias.BuscarPorID(audienciaID, usuarioID)
+		if erro != nil {
+			return erro
+		}
+		if salva.ID == 0 {
+			return erroAudienciaNaoEncontrada
+		}
+		return transacao.Audiencias.Atualizar(audienciaID, audiencia)
+	})
+	if erro == erroAudienciaNaoEncontrada {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// DeletarAudiencia apaga uma audiência do usuário logado. As publicações feitas para ela ficam visíveis só para ele
+func DeletarAudiencia(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	audienciaID, erro := strconv.ParseUint(parametros["audienciaId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//conferindo o dono e apagando juntos
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		salva, erro := transacao.Audiencias.BuscarPorID(audienciaID, usuarioID)
+		if erro != nil {
+			return erro
+		}
+		if salva.ID == 0 {
+			return erroAudienciaNaoEncontrada
+		}
+		return transacao.Audiencias.Deletar(audienciaID)
+	})
+	if erro == erroAudienciaNaoEncontrada {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// verificarAudiencia confere, quando a publicação é para uma audiência, se ela existe e é do autor
+func verificarAudiencia(transacao repositorios.Transacao, publicacao modelos.Publicacao) error {
+	if publicacao.AudienciaID == nil {
+		return nil
+	}
+	audiencia, erro := transacao.Audiencias.BuscarPorID(*publicacao.AudienciaID, publicacao.AutorID)
+	if erro != nil {
+		return erro
+	}
+	if audiencia.ID == 0 {
+		return erroAudienciaNaoEncontrada
+	}
+	return nil
+}
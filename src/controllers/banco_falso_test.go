@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"api/src/banco"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+)
+
+// respostaFalsa é o que o banco falso devolve para um comando: as linhas de um select, ou o id de uma escrita,
+// que afeta uma linha a não ser que nadaAfetado diga o contrário
+type respostaFalsa struct {
+	colunas     int
+	linhas      [][]driver.Value
+	idNovo      int64
+	nadaAfetado bool
+}
+
+// responderConsulta decide a resposta do banco falso para cada comando, cada teste troca pela sua
+var responderConsulta func(query string, args []driver.Value) (respostaFalsa, error)
+
+func init() {
+	sql.Register("falso", driverFalso{})
+	banco.Driver = "falso"
+}
+
+// driverFalso é um driver de database/sql que responde com responderConsulta no lugar do mysql
+type driverFalso struct{}
+
+func (driverFalso) Open(string) (driver.Conn, error) {
+	return conexaoFalsa{}, nil
+}
+
+type conexaoFalsa struct{}
+
+func (conexaoFalsa) Prepare(query string) (driver.Stmt, error) {
+	return comandoFalso{query}, nil
+}
+
+func (conexaoFalsa) Close() error {
+	return nil
+}
+
+// Begin abre uma transação que não desfaz nada, os comandos dentro dela são respondidos como os de fora
+func (conexaoFalsa) Begin() (driver.Tx, error) {
+	return transacaoFalsa{}, nil
+}
+
+type transacaoFalsa struct{}
+
+func (transacaoFalsa) Commit() error {
+	return nil
+}
+
+func (transacaoFalsa) Rollback() error {
+	return nil
+}
+
+type comandoFalso struct {
+	query string
+}
+
+func (comandoFalso) Close() error {
+	return nil
+}
+
+func (comandoFalso) NumInput() int {
+	return -1
+}
+
+func (comando comandoFalso) Exec(args []driver.Value) (driver.Result, error) {
+	resposta, erro := responderConsulta(comando.query, args)
+	if erro != nil {
+		return nil, erro
+	}
+	resultado := resultadoFalso{idNovo: resposta.idNovo, linhasAfetadas: 1}
+	if resposta.nadaAfetado {
+		resultado.linhasAfetadas = 0
+	}
+	return resultado, nil
+}
+
+func (comando comandoFalso) Query(args []driver.Value) (driver.Rows, error) {
+	resposta, erro := responderConsulta(comando.query, args)
+	if erro != nil {
+		return nil, erro
+	}
+	return &linhasFalsas{resposta: resposta}, nil
+}
+
+type resultadoFalso struct {
+	idNovo, linhasAfetadas int64
+}
+
+func (resultado resultadoFalso) LastInsertId() (int64, error) {
+	return resultado.idNovo, nil
+}
+
+func (resultado resultadoFalso) RowsAffected() (int64, error) {
+	return resultado.linhasAfetadas, nil
+}
+
+type linhasFalsas struct {
+	resposta respostaFalsa
+	proxima  int
+}
+
+func (linhas *linhasFalsas) Columns() []string {
+	return make([]string, linhas.resposta.colunas)
+}
+
+func (linhas *linhasFalsas) Close() error {
+	return nil
+}
+
+func (linhas *linhasFalsas) Next(destino []driver.Value) error {
+	if linhas.proxima >= len(linhas.resposta.linhas) {
+		return io.EOF
+	}
+	copy(destino, linhas.resposta.linhas[linhas.proxima])
+	linhas.proxima++
+	return nil
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/banco"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// BloquearUsuario faz o usuário logado bloquear outro usuário. Os dois deixam de se seguir e de se ver
+func BloquearUsuario(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//lendo parametros para obter id do usuario que ele quer bloquear
+	parametros := mux.Vars(r)
+	usuarioID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	if usuarioID == usuarioLogadoID {
+		respostas.Erro(w, http.StatusForbidden, errors.New("não é possível bloquear você mesmo"))
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioLogadoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//bloqueando e desfazendo quem segue quem juntos
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		usuario, erro := transacao.Usuarios.BuscarPorID(usuarioID)
+		if erro != nil {
+			return erro
+		}
+		if usuario.ID == 0 {
+			return erroUsuarioNaoEncontrado
+		}
+		return transacao.Usuarios.Bloquear(usuarioLogadoID, usuarioID)
+	})
+	if erro == erroUsuarioNaoEncontrado {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// DesbloquearUsuario desfaz o bloqueio do usuário logado em outro usuário
+func DesbloquearUsuario(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//lendo parametros para obter id do usuario que ele quer desbloquear
+	parametros := mux.Vars(r)
+	usuarioID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioLogadoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
+	if erro = repositorio.Desbloquear(usuarioLogadoID, usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// BuscarBloqueados traz os usuários que o usuário logado bloqueou
+func BuscarBloqueados(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioLogadoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
+	usuarios, erro := repositorio.BuscarBloqueados(usuarioLogadoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, usuarios)
+}
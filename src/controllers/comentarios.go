@@ -0,0 +1,349 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/banco"
+	"api/src/config"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// erroComentarioNaoEncontrado é retornado quando o id não é de nenhum comentário visível
+var erroComentarioNaoEncontrado = errors.New("comentário não encontrado")
+
+// CriarComentario adiciona um comentário numa publicação, ou uma resposta a outro comentário se paiId for enviado
+func CriarComentario(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	publicacaoID, erro := strconv.ParseUint(parametros["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//lendo requisição
+	corpoRequest, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+	//passando pra struct
+	var comentario modelos.Comentario
+	if erro = json.Unmarshal(corpoRequest, &comentario); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	comentario.PublicacaoID = publicacaoID
+	comentario.AutorID = usuarioID
+	//fazendo verificações
+	if erro = comentario.Preparar(); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//verificando a publicação e o comentário respondido na mesma transação em que o comentário é criado
+	erroDeProfundidade := fmt.Errorf("não é possível responder além de %d níveis", config.ProfundidadeMaximaDeComentarios)
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		publicacao, erro := transacao.Publicacoes.BuscarPorID(publicacaoID, usuarioID)
+		if erro != nil {
+			return erro
+		}
+		if publicacao.ID == 0 {
+			return erroPublicacaoNaoEncontrada
+		}
+		var pai *modelos.Comentario
+		if comentario.PaiID != nil {
+			comentarioPai, erro := transacao.Comentarios.BuscarPorID(*comentario.PaiID)
+			if erro != nil {
+				return erro
+			}
+			if comentarioPai.ID == 0 || comentarioPai.Deletado || comentarioPai.PublicacaoID != publicacaoID {
+				return erroComentarioNaoEncontrado
+			}
+			//comentário de quem tem bloqueio com o usuário logado não aparece para ele, então não pode ser respondido
+			bloqueado, erro := transacao.Usuarios.Bloqueado(usuarioID, comentarioPai.AutorID)
+			if erro != nil {
+				return erro
+			}
+			if bloqueado {
+				return erroComentarioNaoEncontrado
+			}
+			if comentarioPai.Profundidade+1 > uint64(config.ProfundidadeMaximaDeComentarios) {
+				return erroDeProfundidade
+			}
+			pai = &comentarioPai
+		}
+		comentario.ID, erro = transacao.Comentarios.Criar(comentario, pai)
+		if erro != nil {
+			return erro
+		}
+		//quem foi respondido recebe a resposta, e o autor da publicação recebe os comentários
+		if pai != nil {
+			if erro = transacao.Notificacoes.Notificar(pai.AutorID, usuarioID, modelos.NotificacaoResposta, &publicacaoID, &pai.ID, comentario.Conteudo); erro != nil {
+				return erro
+			}
+		}
+		if pai == nil || pai.AutorID != publicacao.AutorID {
+			if erro = transacao.Notificacoes.Notificar(publicacao.AutorID, usuarioID, modelos.NotificacaoComentario, &publicacaoID, nil, comentario.Conteudo); erro != nil {
+				return erro
+			}
+		}
+		comentario, erro = transacao.Comentarios.BuscarPorID(comentario.ID)
+		return erro
+	})
+	if erro == erroPublicacaoNaoEncontrada || erro == erroComentarioNaoEncontrado {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if erro == erroDeProfundidade {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusCreated, comentario)
+}
+
+// BuscarComentarios traz uma página dos comentários de uma publicação, cada um seguido das suas respostas
+func BuscarComentarios(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	publicacaoID, erro := strconv.ParseUint(parametros["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	pagina, limite, erro := extrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	publicacao, erro := repositorios.NovoRepositorioDePublicacoes(db).BuscarPorID(publicacaoID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if publicacao.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, erroPublicacaoNaoEncontrada)
+		return
+	}
+	comentarios, erro := repositorios.NovoRepositorioDeComentarios(db).BuscarArvore(publicacaoID, 0, usuarioID, pagina, limite)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, comentarios)
+}
+
+// BuscarRespostasDoComentario traz uma página das respostas de um comentário, em profundidade
+func BuscarRespostasDoComentario(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	comentarioID, erro := strconv.ParseUint(parametros["comentarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	pagina, limite, erro := extrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	repositorio := repositorios.NovoRepositorioDeComentarios(db)
+	comentario, erro := repositorio.BuscarPorID(comentarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if comentario.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, erroComentarioNaoEncontrado)
+		return
+	}
+	//as respostas só são visíveis enquanto a publicação for
+	publicacao, erro := repositorios.NovoRepositorioDePublicacoes(db).BuscarPorID(comentario.PublicacaoID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if publicacao.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, erroComentarioNaoEncontrado)
+		return
+	}
+	comentarios, erro := repositorio.BuscarArvore(comentario.PublicacaoID, comentarioID, usuarioID, pagina, limite)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, comentarios)
+}
+
+// AtualizarComentario edita o conteúdo de um comentário, o que só o autor dele pode fazer
+func AtualizarComentario(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	comentarioID, erro := strconv.ParseUint(parametros["comentarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//lendo requisição
+	corpoRequest, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+	//passando pra struct
+	var comentario modelos.Comentario
+	if erro = json.Unmarshal(corpoRequest, &comentario); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//fazendo verificações
+	if erro = comentario.Preparar(); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	repositorio := repositorios.NovoRepositorioDeComentarios(db)
+	comentarioSalvo, erro := repositorio.BuscarPorID(comentarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if comentarioSalvo.ID == 0 || comentarioSalvo.Deletado {
+		respostas.Erro(w, http.StatusNotFound, erroComentarioNaoEncontrado)
+		return
+	}
+	//vendo se o id de quem fez o comentário é o mesmo de quem ta logado
+	if comentarioSalvo.AutorID != usuarioID {
+		respostas.Erro(w, http.StatusForbidden, errors.New("não é possível editar um comentário que não seja seu"))
+		return
+	}
+	if erro = repositorio.Atualizar(comentarioID, comentario); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// DeletarComentario apaga um comentário. Pode ser feito pelo autor dele ou pelo autor da publicação
+func DeletarComentario(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	comentarioID, erro := strconv.ParseUint(parametros["comentarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//verificando as permissões e deletando dentro da mesma transação
+	erroDePermissao := errors.New("não é possível deletar um comentário que não seja seu ou de uma publicação sua")
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		comentario, erro := transacao.Comentarios.BuscarPorID(comentarioID)
+		if erro != nil {
+			return erro
+		}
+		if comentario.ID == 0 || comentario.Deletado {
+			return erroComentarioNaoEncontrado
+		}
+		publicacao, erro := transacao.Publicacoes.BuscarPorID(comentario.PublicacaoID, usuarioID)
+		if erro != nil {
+			return erro
+		}
+		if publicacao.ID == 0 {
+			return erroComentarioNaoEncontrado
+		}
+		if comentario.AutorID != usuarioID && publicacao.AutorID != usuarioID {
+			return erroDePermissao
+		}
+		return transacao.Comentarios.Deletar(comentario)
+	})
+	if erro == erroComentarioNaoEncontrado {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if erro == erroDePermissao {
+		respostas.Erro(w, http.StatusForbidden, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,449 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/banco"
+	"api/src/eventos"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	//erroConversaNaoEncontrada é retornado quando a conversa não existe ou o usuário logado não participa dela
+	erroConversaNaoEncontrada = errors.New("conversa não encontrada")
+	//erroUsuarioNaoEncontrado é retornado quando algum participante pedido não existe
+	erroUsuarioNaoEncontrado = errors.New("usuário não encontrado")
+)
+
+// erroMensagemNaoPermitida é retornado quando um participante não aceita mensagens do usuário logado
+type erroMensagemNaoPermitida struct {
+	nick string
+}
+
+func (erro erroMensagemNaoPermitida) Error() string {
+	return fmt.Sprintf("%s não aceita mensagens suas", erro.nick)
+}
+
+// CriarConversa começa uma conversa do usuário logado com outro usuário ou com um grupo pequeno.
+// Se já existir uma conversa a dois com o mesmo usuário ela é retornada em vez de criar outra
+func CriarConversa(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//lendo requisição
+	corpoRequest, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+	//passando pra struct
+	var conversa modelos.Conversa
+	if erro = json.Unmarshal(corpoRequest, &conversa); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//fazendo verificações
+	if erro = conversa.Preparar(usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//conferindo se todos os participantes aceitam mensagens do usuário logado antes de criar
+	var conversaID uint64
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		for _, participanteID := range conversa.ParticipantesIDs {
+			if erro := verificarPermissaoDeMensagem(transacao.Usuarios, usuarioID, participanteID); erro != nil {
+				return erro
+			}
+		}
+		if !conversa.Grupo {
+			if conversaID, erro = transacao.Conversas.BuscarConversaDireta(usuarioID, conversa.ParticipantesIDs[0]); erro != nil || conversaID != 0 {
+				return erro
+			}
+		}
+		conversaID, erro = transacao.Conversas.Criar(conversa, usuarioID)
+		return erro
+	})
+	var naoPermitida erroMensagemNaoPermitida
+	if errors.As(erro, &naoPermitida) {
+		respostas.Erro(w, http.StatusForbidden, erro)
+		return
+	}
+	if erro == erroUsuarioNaoEncontrado {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	conversa, erro = repositorios.NovoRepositorioDeConversas(db).BuscarPorID(conversaID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusCreated, conversa)
+}
+
+// BuscarConversas traz uma página das conversas do usuário logado junto com o total de mensagens não lidas
+func BuscarConversas(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	pagina, limite, erro := extrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	repositorio := repositorios.NovoRepositorioDeConversas(db)
+	var conversas modelos.Conversas
+	if conversas.NaoLidas, erro = repositorio.ContarNaoLidas(usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if conversas.Conversas, erro = repositorio.Buscar(usuarioID, pagina, limite); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, conversas)
+}
+
+// BuscarMensagensDaConversa traz uma página do histórico de uma conversa do usuário logado, das mais recentes para as mais antigas
+func BuscarMensagensDaConversa(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	conversaID, erro := strconv.ParseUint(parametros["conversaId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	pagina, limite, erro := extrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco, conversas de outros usuários não são encontradas
+	repositorio := repositorios.NovoRepositorioDeConversas(db)
+	conversa, erro := repositorio.BuscarPorID(conversaID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if conversa.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, erroConversaNaoEncontrada)
+		return
+	}
+	mensagens, erro := repositorio.BuscarMensagens(conversaID, pagina, limite)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, mensagens)
+}
+
+// EnviarMensagem manda uma mensagem numa conversa do usuário logado
+func EnviarMensagem(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	conversaID, erro := strconv.ParseUint(parametros["conversaId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//lendo requisição
+	corpoRequest, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+	//passando pra struct
+	var mensagem modelos.Mensagem
+	if erro = json.Unmarshal(corpoRequest, &mensagem); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	mensagem.ConversaID = conversaID
+	mensagem.RemetenteID = usuarioID
+	//fazendo verificações
+	if erro = mensagem.Preparar(); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//numa conversa a dois a permissão é conferida de novo a cada mensagem, já que o outro pode ter mudado de ideia
+	var participantes []uint64
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		conversa, erro := transacao.Conversas.BuscarPorID(conversaID, usuarioID)
+		if erro != nil {
+			return erro
+		}
+		if conversa.ID == 0 {
+			return erroConversaNaoEncontrada
+		}
+		for _, participante := range conversa.Participantes {
+			if participante.ID == usuarioID {
+				continue
+			}
+			participantes = append(participantes, participante.ID)
+			if conversa.Grupo {
+				continue
+			}
+			if erro = verificarPermissaoDeMensagem(transacao.Usuarios, usuarioID, participante.ID); erro != nil {
+				return erro
+			}
+		}
+		mensagem.ID, erro = transacao.Conversas.CriarMensagem(mensagem)
+		return erro
+	})
+	var naoPermitida erroMensagemNaoPermitida
+	if errors.As(erro, &naoPermitida) {
+		respostas.Erro(w, http.StatusForbidden, erro)
+		return
+	}
+	if erro == erroConversaNaoEncontrada || erro == erroUsuarioNaoEncontrado {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	mensagem, erro = repositorios.NovoRepositorioDeConversas(db).BuscarMensagem(mensagem.ID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	//a mensagem já foi gravada, então um erro ao avisar em tempo real só é registrado
+	if erro = eventos.Publicar(participantes, "mensagem", mensagem); erro != nil {
+		log.Printf("erro ao transmitir a mensagem %d: %v", mensagem.ID, erro)
+	}
+	respostas.JSON(w, http.StatusCreated, mensagem)
+}
+
+// MarcarConversaComoLida marca todas as mensagens de uma conversa como lidas pelo usuário logado
+func MarcarConversaComoLida(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	conversaID, erro := strconv.ParseUint(parametros["conversaId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	repositorio := repositorios.NovoRepositorioDeConversas(db)
+	conversa, erro := repositorio.BuscarPorID(conversaID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if conversa.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, erroConversaNaoEncontrada)
+		return
+	}
+	if erro = repositorio.MarcarComoLida(conversaID, usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// DeletarMensagem apaga uma mensagem, o que só quem mandou pode fazer
+func DeletarMensagem(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	mensagemID, erro := strconv.ParseUint(parametros["mensagemId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	repositorio := repositorios.NovoRepositorioDeConversas(db)
+	mensagem, erro := repositorio.BuscarMensagem(mensagemID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if mensagem.ID == 0 || mensagem.Deletada {
+		respostas.Erro(w, http.StatusNotFound, errors.New("mensagem não encontrada"))
+		return
+	}
+	//vendo se o id de quem mandou a mensagem é o mesmo de quem ta logado
+	if mensagem.RemetenteID != usuarioID {
+		respostas.Erro(w, http.StatusForbidden, errors.New("não é possível deletar uma mensagem que não seja sua"))
+		return
+	}
+	if erro = repositorio.DeletarMensagem(mensagemID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// AtualizarPermissaoDeMensagens muda quem pode mandar mensagens diretas para o usuário logado
+func AtualizarPermissaoDeMensagens(w http.ResponseWriter, r *http.Request) {
+	//lendo parametros
+	parametros := mux.Vars(r)
+	usuarioID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioIDtoken, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//se o usuario logado estiver tentando atualizar os dados de outro usuario
+	if usuarioID != usuarioIDtoken {
+		respostas.Erro(w, http.StatusForbidden, errors.New("não é possível atualizar um usuário que não seja o logado"))
+		return
+	}
+	//lendo requisição
+	corpoRequest, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+	//passando pra struct
+	var corpo struct {
+		Permissao string `json:"permissao"`
+	}
+	if erro = json.Unmarshal(corpoRequest, &corpo); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	if !modelos.PermissaoDeMensagensValida(corpo.Permissao) {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("a permissão precisa ser todos, seguidos ou ninguem"))
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
+	if erro = repositorio.AtualizarPermissaoDeMensagens(usuarioID, corpo.Permissao); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// verificarPermissaoDeMensagem confere se destinatarioID aceita mensagens de remetenteID: de todos, só de quem
+// ele segue (pela tabela de seguidores) ou de ninguém. Com bloqueio entre os dois não há mensagem
+func verificarPermissaoDeMensagem(usuarios *repositorios.Usuarios, remetenteID, destinatarioID uint64) error {
+	destinatario, erro := usuarios.BuscarPorID(destinatarioID)
+	if erro != nil {
+		return erro
+	}
+	if destinatario.ID == 0 {
+		return erroUsuarioNaoEncontrado
+	}
+	//com bloqueio entre os dois o destinatário é tratado como inexistente
+	bloqueado, erro := usuarios.Bloqueado(remetenteID, destinatarioID)
+	if erro != nil {
+		return erro
+	}
+	if bloqueado {
+		return erroUsuarioNaoEncontrado
+	}
+	permissao, erro := usuarios.BuscarPermissaoDeMensagens(destinatarioID)
+	if erro != nil {
+		return erro
+	}
+	switch permissao {
+	case modelos.MensagensDeTodos:
+		return nil
+	case modelos.MensagensDeSeguidos:
+		segue, erro := usuarios.Segue(destinatarioID, remetenteID)
+		if erro != nil || segue {
+			return erro
+		}
+	}
+	return erroMensagemNaoPermitida{destinatario.Nick}
+}
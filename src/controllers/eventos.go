@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/eventos"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	//intervaloDeBatimento é de quanto em quanto tempo a conexão de eventos manda algo mesmo sem eventos,
+	//para proxies não derrubarem a conexão parada e o cliente saber que ela continua viva
+	intervaloDeBatimento = 25 * time.Second
+	//esperaPorCliente é quanto tempo um cliente WebSocket pode ficar sem responder antes de ser desconectado
+	esperaPorCliente = 2 * intervaloDeBatimento
+)
+
+// eventoSincronizar avisa o cliente que eventos podem ter se perdido e ele deve recarregar pelas rotas normais
+const eventoSincronizar = "sincronizar"
+
+// AcompanharEventos mantém a conexão aberta empurrando para o usuário logado as novas publicações de quem ele segue,
+// novos seguidores e interações com ele. Funciona por WebSocket, se a requisição pedir, ou por Server-Sent Events.
+// O id do último evento recebido (Last-Event-ID, ou ultimoEventoId na query para WebSocket) retoma de onde parou.
+// Como o navegador não manda Authorization nesses dois, o token também pode vir em ?token= ou no Sec-WebSocket-Protocol
+func AcompanharEventos(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	ultimoEventoID := r.Header.Get("Last-Event-ID")
+	if ultimoEventoID == "" {
+		ultimoEventoID = r.URL.Query().Get("ultimoEventoId")
+	}
+	if eventos.EhWebSocket(r) {
+		transmitirPorWebSocket(w, r, usuarioID, ultimoEventoID)
+		return
+	}
+	transmitirPorSSE(w, r, usuarioID, ultimoEventoID)
+}
+
+// transmitirPorSSE envia os eventos como text/event-stream até o cliente desconectar
+func transmitirPorSSE(w http.ResponseWriter, r *http.Request, usuarioID uint64, ultimoEventoID string) {
+	descarregador, ok := w.(http.Flusher)
+	if !ok {
+		respostas.Erro(w, http.StatusInternalServerError, errors.New("o servidor não permite enviar eventos"))
+		return
+	}
+	assinatura, perdidos, lacuna := eventos.Assinar(usuarioID, ultimoEventoID)
+	defer eventos.Cancelar(assinatura)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	//sem isso o nginx segura os eventos no buffer
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", (3 * time.Second).Milliseconds())
+	if lacuna {
+		fmt.Fprintf(w, "event: %s\ndata: {}\n\n", eventoSincronizar)
+	}
+	for _, evento := range perdidos {
+		escreverEventoSSE(w, evento)
+	}
+	descarregador.Flush()
+	batimento := time.NewTicker(intervaloDeBatimento)
+	defer batimento.Stop()
+	for {
+		select {
+		case evento := <-assinatura.Eventos():
+			if erro := escreverEventoSSE(w, evento); erro != nil {
+				return
+			}
+		case <-batimento.C:
+			//linhas começando com : são comentários, que o cliente ignora
+			if _, erro := fmt.Fprint(w, ": batimento\n\n"); erro != nil {
+				return
+			}
+		case <-assinatura.Descartada():
+			//o cliente ficou para trás, ao reconectar com o último id ele recebe o que faltou
+			return
+		case <-r.Context().Done():
+			return
+		}
+		descarregador.Flush()
+	}
+}
+
+// escreverEventoSSE escreve um evento no formato de Server-Sent Events
+func escreverEventoSSE(w http.ResponseWriter, evento eventos.Evento) error {
+	_, erro := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", evento.ID, evento.Tipo, evento.Dados)
+	return erro
+}
+
+// transmitirPorWebSocket envia cada evento como uma mensagem JSON até o cliente desconectar
+func transmitirPorWebSocket(w http.ResponseWriter, r *http.Request, usuarioID uint64, ultimoEventoID string) {
+	conexao, erro := eventos.AceitarWebSocket(w, r, autenticacao.ProtocoloDoToken)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	defer conexao.Fechar()
+	assinatura, perdidos, lacuna := eventos.Assinar(usuarioID, ultimoEventoID)
+	defer eventos.Cancelar(assinatura)
+	//a leitura roda à parte para responder pings e perceber quando o cliente some
+	desconectou := make(chan struct{})
+	go func() {
+		conexao.Ler(esperaPorCliente)
+		close(desconectou)
+	}()
+	if lacuna {
+		if erro = escreverEventoWebSocket(conexao, eventos.Evento{Tipo: eventoSincronizar, Dados: json.RawMessage("{}")}); erro != nil {
+			return
+		}
+	}
+	for _, evento := range perdidos {
+		if erro = escreverEventoWebSocket(conexao, evento); erro != nil {
+			return
+		}
+	}
+	batimento := time.NewTicker(intervaloDeBatimento)
+	defer batimento.Stop()
+	for {
+		select {
+		case evento := <-assinatura.Eventos():
+			if erro = escreverEventoWebSocket(conexao, evento); erro != nil {
+				return
+			}
+		case <-batimento.C:
+			if erro = conexao.EscreverPing(); erro != nil {
+				return
+			}
+		case <-assinatura.Descartada():
+			return
+		case <-desconectou:
+			return
+		}
+	}
+}
+
+// escreverEventoWebSocket manda o evento como JSON numa mensagem de texto
+func escreverEventoWebSocket(conexao *eventos.ConexaoWebSocket, evento eventos.Evento) error {
+	mensagem, erro := json.Marshal(evento)
+	if erro != nil {
+		return erro
+	}
+	return conexao.EscreverTexto(mensagem)
+}
+
+// transmitirPublicacao avisa os seguidores do autor conectados que podem vê-la que há uma publicação nova.
+// A publicação já foi criada, então um erro aqui só é registrado
+func transmitirPublicacao(db *sql.DB, publicacao modelos.Publicacao) {
+	leitores, erro := repositorios.NovoRepositorioDePublicacoes(db).BuscarIDsDosLeitores(publicacao.ID)
+	if erro == nil {
+		erro = eventos.Publicar(leitores, "publicacao", publicacao)
+	}
+	if erro != nil {
+		log.Printf("erro ao transmitir a publicação %d: %v", publicacao.ID, erro)
+	}
+}
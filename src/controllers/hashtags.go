@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/banco"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// BuscarPublicacoesPorHashtag traz as publicações com uma hashtag, das mais recentes para as mais antigas
+func BuscarPublicacoesPorHashtag(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro}), aceitando a tag com ou sem # e em qualquer caixa
+	tag := modelos.NormalizarHashtag(mux.Vars(r)["tag"])
+	if tag == "" {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("a hashtag não pode estar em branco"))
+		return
+	}
+	pagina, limite, erro := extrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	repositorio := repositorios.NovoRepositorioDePublicacoes(db)
+	publicacoes, erro := repositorio.BuscarPorHashtag(tag, usuarioID, pagina, limite)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, publicacoes)
+}
+
+// BuscarHashtagsEmAlta traz as hashtags cujo uso mais acelerou, calculadas periodicamente em segundo plano
+func BuscarHashtagsEmAlta(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	_, limite, erro := extrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	repositorio := repositorios.NovoRepositorioDeHashtags(db)
+	hashtags, erro := repositorio.BuscarEmAlta(limite)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, hashtags)
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/banco"
+	"api/src/cache"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"errors"
+	"net/http"
+)
+
+// BuscarMetricasDoCache retorna quantas buscas acertaram e quantas falharam no cache. São dados internos,
+// então só administradores veem
+func BuscarMetricasDoCache(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioLogadoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	admin, erro := repositorios.NovoRepositorioDeUsuarios(db).EhAdmin(usuarioLogadoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if !admin {
+		respostas.Erro(w, http.StatusForbidden, errors.New("só administradores podem ver as métricas"))
+		return
+	}
+	respostas.JSON(w, http.StatusOK, cache.BuscarMetricas())
+}
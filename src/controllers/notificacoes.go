@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/banco"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// BuscarNotificacoes traz uma página das notificações do usuário logado junto com quantas ele ainda não leu
+func BuscarNotificacoes(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	pagina, limite, erro := extrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	repositorio := repositorios.NovoRepositorioDeNotificacoes(db)
+	var notificacoes modelos.Notificacoes
+	if notificacoes.NaoLidas, erro = repositorio.ContarNaoLidas(usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if notificacoes.Notificacoes, erro = repositorio.Buscar(usuarioID, pagina, limite); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, notificacoes)
+}
+
+// MarcarNotificacaoComoLida marca uma notificação do usuário logado como lida
+func MarcarNotificacaoComoLida(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	notificacaoID, erro := strconv.ParseUint(parametros["notificacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco, notificações de outros usuários não são encontradas
+	repositorio := repositorios.NovoRepositorioDeNotificacoes(db)
+	existe, erro := repositorio.MarcarComoLida(notificacaoID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if !existe {
+		respostas.Erro(w, http.StatusNotFound, errors.New("notificação não encontrada"))
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// MarcarTodasNotificacoesComoLidas marca todas as notificações do usuário logado como lidas
+func MarcarTodasNotificacoesComoLidas(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	repositorio := repositorios.NovoRepositorioDeNotificacoes(db)
+	if erro = repositorio.MarcarTodasComoLidas(usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+const (
+	//limitePadrao é quantos itens vêm por página quando o limite não é informado
+	limitePadrao = 20
+	//limiteMaximo é o maior limite aceito por página
+	limiteMaximo = 100
+)
+
+// extrairPaginacao lê os parâmetros pagina (começando em 1) e limite da query string (url?pagina=2&limite=20)
+func extrairPaginacao(r *http.Request) (int, int, error) {
+	pagina, limite := 1, limitePadrao
+	var erro error
+	if valor := r.URL.Query().Get("pagina"); valor != "" {
+		if pagina, erro = strconv.Atoi(valor); erro != nil || pagina < 1 {
+			return 0, 0, errors.New("a página precisa ser um número maior que zero")
+		}
+	}
+	if valor := r.URL.Query().Get("limite"); valor != "" {
+		if limite, erro = strconv.Atoi(valor); erro != nil || limite < 1 || limite > limiteMaximo {
+			return 0, 0, errors.New("o limite precisa ser um número entre 1 e 100")
+		}
+	}
+	return pagina, limite, nil
+}
@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/banco"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// erroContaPrivada é retornado quando o usuário logado tenta ver o que uma conta privada que ele não segue esconde
+var erroContaPrivada = errors.New("esta conta é privada, só seguidores aprovados podem ver")
+
+// erroPedidoNaoEncontrado é retornado quando não há pedido para seguir do usuário pedido
+var erroPedidoNaoEncontrado = errors.New("pedido para seguir não encontrado")
+
+// AtualizarPrivacidade torna a conta do usuário logado privada ou pública
+func AtualizarPrivacidade(w http.ResponseWriter, r *http.Request) {
+	//lendo parametros
+	parametros := mux.Vars(r)
+	usuarioID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioIDtoken, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//se o usuario logado estiver tentando atualizar os dados de outro usuario
+	if usuarioID != usuarioIDtoken {
+		respostas.Erro(w, http.StatusForbidden, errors.New("não é possível atualizar um usuário que não seja o logado"))
+		return
+	}
+	//lendo requisição
+	corpoRequest, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+	//passando pra struct
+	var corpo struct {
+		Privado *bool `json:"privado"`
+	}
+	if erro = json.Unmarshal(corpoRequest, &corpo); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	if corpo.Privado == nil {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("o campo privado é obrigatório"))
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//ao virar pública os pedidos pendentes são aprovados junto
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		return transacao.Usuarios.AtualizarPrivacidade(usuarioID, *corpo.Privado)
+	})
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// BuscarPedidosParaSeguir traz quem pediu para seguir o usuário logado e ainda espera resposta
+func BuscarPedidosParaSeguir(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioLogadoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
+	usuarios, erro := repositorio.BuscarPedidosParaSeguir(usuarioLogadoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, usuarios)
+}
+
+// AprovarPedidoParaSeguir faz quem pediu passar a seguir o usuário logado
+func AprovarPedidoParaSeguir(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//lendo parametros para obter id de quem pediu
+	parametros := mux.Vars(r)
+	seguidorID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioLogadoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//aprovando e avisando quem pediu juntos
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		existia, erro := transacao.Usuarios.AprovarPedidoParaSeguir(usuarioLogadoID, seguidorID)
+		if erro != nil {
+			return erro
+		}
+		if !existia {
+			return erroPedidoNaoEncontrado
+		}
+		return transacao.Notificacoes.Notificar(seguidorID, usuarioLogadoID, modelos.NotificacaoPedidoAprovado, nil, nil, "")
+	})
+	if erro == erroPedidoNaoEncontrado {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// RejeitarPedidoParaSeguir recusa o pedido para seguir o usuário logado, sem avisar quem pediu
+func RejeitarPedidoParaSeguir(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//lendo parametros para obter id de quem pediu
+	parametros := mux.Vars(r)
+	seguidorID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioLogadoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
+	existia, erro := repositorio.RejeitarPedidoParaSeguir(usuarioLogadoID, seguidorID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if !existia {
+		respostas.Erro(w, http.StatusNotFound, erroPedidoNaoEncontrado)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// RemoverSeguidor faz um seguidor do usuário logado deixar de segui-lo, sem bloquear
+func RemoverSeguidor(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//lendo parametros para obter id do seguidor
+	parametros := mux.Vars(r)
+	seguidorID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioLogadoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//desfazendo a relação e os contadores juntos
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		return transacao.Usuarios.PararDeSeguir(usuarioLogadoID, seguidorID)
+	})
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
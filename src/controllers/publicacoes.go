@@ -3,6 +3,7 @@ package controllers
 import (
 	"api/src/autenticacao"
 	"api/src/banco"
+	"api/src/busca"
 	"api/src/modelos"
 	"api/src/repositorios"
 	"api/src/respostas"
@@ -11,10 +12,15 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
+// erroPublicacaoNaoEncontrada é retornado quando o id não é de nenhuma publicação visível
+var erroPublicacaoNaoEncontrada = errors.New("publicação não encontrada")
+
 // CriarPublicacao adciona uma nova publicacao no db
 func CriarPublicacao(w http.ResponseWriter, r *http.Request) {
 	//Obtendo ID do token pra saber qual usuario está logado
@@ -42,19 +48,53 @@ func CriarPublicacao(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	//abrindo db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarEscrita(usuarioID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 	defer db.Close()
-	//usando metodos do repositorio para interagir com banco
-	repositorio := repositorios.NovoRepositorioDePublicacoes(db)
-	publicacao.ID, erro = repositorio.Criar(publicacao)
+	//criando a publicação e a primeira revisão dela juntas, conferindo antes a audiência e a citada se houver
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		if erro := verificarAudiencia(transacao, publicacao); erro != nil {
+			return erro
+		}
+		if publicacao.CitadaID == nil {
+			publicacao.ID, erro = transacao.Publicacoes.Criar(publicacao)
+			return erro
+		}
+		citada, erro := transacao.Publicacoes.BuscarPorID(*publicacao.CitadaID, usuarioID)
+		if erro != nil {
+			return erro
+		}
+		if citada.ID == 0 {
+			return erroPublicacaoNaoEncontrada
+		}
+		if publicacao.ID, erro = transacao.Publicacoes.Criar(publicacao); erro != nil {
+			return erro
+		}
+		return transacao.Notificacoes.Notificar(citada.AutorID, usuarioID, modelos.NotificacaoCitacao, &citada.ID, nil,
+			publicacao.Titulo+"\n"+publicacao.Conteudo)
+	})
+	if erro == erroPublicacaoNaoEncontrada {
+		respostas.Erro(w, http.StatusUnprocessableEntity, errors.New("a publicação citada não foi encontrada"))
+		return
+	}
+	if erro == erroAudienciaNaoEncontrada || erro == repositorios.ErroAnexoIndisponivel {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
+	//lendo de volta para responder com as menções já resolvidas para usuários
+	publicacao, erro = repositorios.NovoRepositorioDePublicacoes(db).BuscarPorID(publicacao.ID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	transmitirPublicacao(db, publicacao)
 	respostas.JSON(w, http.StatusCreated, publicacao)
 
 }
@@ -68,7 +108,7 @@ func BuscarPublicacoes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	//abrino db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarLeitura(usuarioID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
@@ -95,8 +135,14 @@ func BuscarPublicacao(w http.ResponseWriter, r *http.Request) {
 		respostas.Erro(w, http.StatusBadRequest, erro)
 		return
 	}
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
 	//abrino db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarLeitura(usuarioLogadoID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
@@ -104,11 +150,16 @@ func BuscarPublicacao(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 	//usando metodos do repositorio para interagir com banco
 	repositorio := repositorios.NovoRepositorioDePublicacoes(db)
-	publicacao, erro := repositorio.BuscarPorID(publicacaoID)
+	publicacao, erro := repositorio.BuscarPorID(publicacaoID, usuarioLogadoID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
+	if publicacao.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, erroPublicacaoNaoEncontrada)
+		return
+	}
+	definirETag(w, publicacao.Versao)
 	respostas.JSON(w, http.StatusOK, publicacao)
 
 }
@@ -129,8 +180,14 @@ func AtualizarPublicacao(w http.ResponseWriter, r *http.Request) {
 		respostas.Erro(w, http.StatusBadRequest, erro)
 		return
 	}
+	//lendo a versão que o cliente tinha quando editou
+	versao, erro := versaoDoIfMatch(r)
+	if erro != nil {
+		respostas.Erro(w, statusDoIfMatch(erro), erro)
+		return
+	}
 	//abrindo db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarEscrita(usuarioID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
@@ -138,11 +195,15 @@ func AtualizarPublicacao(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 	//usando metodos do repositorio para interagir com banco
 	repositorio := repositorios.NovoRepositorioDePublicacoes(db)
-	publicacaoSalva, erro := repositorio.BuscarPorID(publicacaoID)
+	publicacaoSalva, erro := repositorio.BuscarPorID(publicacaoID, usuarioID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
+	if publicacaoSalva.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, erroPublicacaoNaoEncontrada)
+		return
+	}
 	//vendo se o id de quem fez a publi é o mesmo de quem ta logado
 	if publicacaoSalva.AutorID != usuarioID {
 		respostas.Erro(w, http.StatusForbidden, errors.New("não é possível atualizar uma puclicação que não seja sua"))
@@ -161,16 +222,39 @@ func AtualizarPublicacao(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	publicacao.AutorID = usuarioID
+	publicacao.Versao = versao
+	//sem visibilidade no corpo a publicação continua visível para quem já era
+	if publicacao.Visibilidade == "" {
+		publicacao.Visibilidade = publicacaoSalva.Visibilidade
+		publicacao.AudienciaID = publicacaoSalva.AudienciaID
+	}
 	//fazendo verificações
 	if erro = publicacao.Preparar(); erro != nil {
 		respostas.Erro(w, http.StatusBadRequest, erro)
 		return
 	}
-	//usando repositorios denovo para agora atualizar de fato
-	if erro = repositorio.Atualizar(publicacaoID, publicacao); erro != nil {
+	//usando repositorios denovo para agora atualizar de fato, junto com a nova revisão
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		if erro := verificarAudiencia(transacao, publicacao); erro != nil {
+			return erro
+		}
+		return transacao.Publicacoes.Atualizar(publicacaoID, publicacao)
+	})
+	if erro == repositorios.ErroVersaoDesatualizada {
+		respostas.Erro(w, http.StatusPreconditionFailed, erro)
+		return
+	}
+	if erro == erroAudienciaNaoEncontrada {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
+	if versao != 0 {
+		definirETag(w, versao+1)
+	}
 	respostas.JSON(w, http.StatusNoContent, nil)
 
 }
@@ -192,52 +276,136 @@ func DeletarPublicacao(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	//abrindo db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//verificando o autor e deletando dentro da mesma transação
+	erroDePermissao := errors.New("não é possível deletar uma puclicação que não seja sua")
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		publicacaoSalva, erro := transacao.Publicacoes.BuscarPorID(publicacaoID, usuarioID)
+		if erro != nil {
+			return erro
+		}
+		if publicacaoSalva.ID == 0 {
+			return erroPublicacaoNaoEncontrada
+		}
+		//vendo se o id de quem fez a publi é o mesmo de quem ta logado
+		if publicacaoSalva.AutorID != usuarioID {
+			return erroDePermissao
+		}
+		//usando repositorios para deletar de fato a publicacao
+		return transacao.Publicacoes.Deletar(publicacaoID)
+	})
+	if erro == erroPublicacaoNaoEncontrada {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if erro == erroDePermissao {
+		respostas.Erro(w, http.StatusForbidden, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// BuscarPublicacoesPorUsuario traz todas publicações de um usuário
+func BuscarPublicacoesPorUsuario(w http.ResponseWriter, r *http.Request) {
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	//aqui alem de converter de str p uint to pegando só o parametro usuarioId
+	usuarioID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioLogadoID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 	defer db.Close()
 	//usando metodos do repositorio para interagir com banco
-	repositorio := repositorios.NovoRepositorioDePublicacoes(db)
-	publicacaoSalva, erro := repositorio.BuscarPorID(publicacaoID)
+	//com bloqueio entre os dois o usuário responde como se não existisse
+	bloqueado, erro := repositorios.NovoRepositorioDeUsuarios(db).Bloqueado(usuarioLogadoID, usuarioID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
-	//vendo se o id de quem fez a publi é o mesmo de quem ta logado
-	if publicacaoSalva.AutorID != usuarioID {
-		respostas.Erro(w, http.StatusForbidden, errors.New("não é possível deletar uma puclicação que não seja sua"))
+	if bloqueado {
+		respostas.Erro(w, http.StatusNotFound, erroUsuarioNaoEncontrado)
 		return
 	}
-	//usando repositorios para deletar de fato a publicacao
-	if erro = repositorio.Deletar(publicacaoID); erro != nil {
+	podeVer, erro := repositorios.NovoRepositorioDeUsuarios(db).PodeVer(usuarioLogadoID, usuarioID)
+	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
-	respostas.JSON(w, http.StatusNoContent, nil)
+	if !podeVer {
+		respostas.Erro(w, http.StatusForbidden, erroContaPrivada)
+		return
+	}
+	repositorio := repositorios.NovoRepositorioDePublicacoes(db)
+	publicacoes, erro := repositorio.BuscarPorUsuario(usuarioID, usuarioLogadoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, publicacoes)
 }
 
-// BuscarPublicacoesPorUsuario traz todas publicações de um usuário
-func BuscarPublicacoesPorUsuario(w http.ResponseWriter, r *http.Request) {
+// BuscarMencoesDoUsuario traz as publicações que mencionam um usuário
+func BuscarMencoesDoUsuario(w http.ResponseWriter, r *http.Request) {
 	//pegando parametro (url/{parametro})
 	parametros := mux.Vars(r)
-	//aqui alem de converter de str p uint to pegando só o parametro usuarioId
 	usuarioID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
 	if erro != nil {
 		respostas.Erro(w, http.StatusBadRequest, erro)
 		return
 	}
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	pagina, limite, erro := extrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
 	//abrindo db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarLeitura(usuarioLogadoID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 	defer db.Close()
 	//usando metodos do repositorio para interagir com banco
+	//com bloqueio entre os dois o usuário responde como se não existisse
+	bloqueado, erro := repositorios.NovoRepositorioDeUsuarios(db).Bloqueado(usuarioLogadoID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if bloqueado {
+		respostas.Erro(w, http.StatusNotFound, erroUsuarioNaoEncontrado)
+		return
+	}
 	repositorio := repositorios.NovoRepositorioDePublicacoes(db)
-	publicacoes, erro := repositorio.BuscarPorUsuario(usuarioID)
+	publicacoes, erro := repositorio.BuscarMencoes(usuarioID, usuarioLogadoID, pagina, limite)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
@@ -245,8 +413,14 @@ func BuscarPublicacoesPorUsuario(w http.ResponseWriter, r *http.Request) {
 	respostas.JSON(w, http.StatusOK, publicacoes)
 }
 
-// CurtirPublicacao incrementa o número de curtidas de uma publicacao
+// CurtirPublicacao registra a curtida do usuário logado numa publicacao
 func CurtirPublicacao(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
 	//pegando parametro (url/{parametro})
 	parametros := mux.Vars(r)
 	//aqui alem de converter de str p uint to pegando só o parametro publicacaoId
@@ -256,15 +430,24 @@ func CurtirPublicacao(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	//abrindo db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarEscrita(usuarioID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 	defer db.Close()
-	//usando metodos do repositorio para interagir com banco
-	repositorio := repositorios.NovoRepositorioDePublicacoes(db)
-	erro = repositorio.Curtir(publicacaoID)
+	//registrando a curtida, atualizando o contador e avisando o autor juntos, repetir não muda nada
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		nova, erro := transacao.Publicacoes.Curtir(publicacaoID, usuarioID)
+		if erro != nil || !nova {
+			return erro
+		}
+		return notificarAutor(transacao, publicacaoID, usuarioID, modelos.NotificacaoCurtida)
+	})
+	if erro == repositorios.ErroPublicacaoNaoEncontrada {
+		respostas.Erro(w, http.StatusNotFound, erroPublicacaoNaoEncontrada)
+		return
+	}
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
@@ -272,8 +455,14 @@ func CurtirPublicacao(w http.ResponseWriter, r *http.Request) {
 	respostas.JSON(w, http.StatusNoContent, nil)
 }
 
-// DescurtirPublicacao decrementa o número de curtidas de uma publicacao
+// DescurtirPublicacao desfaz a curtida do usuário logado numa publicacao
 func DescurtirPublicacao(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
 	//pegando parametro (url/{parametro})
 	parametros := mux.Vars(r)
 	//aqui alem de converter de str p uint to pegando só o parametro publicacaoId
@@ -283,7 +472,125 @@ func DescurtirPublicacao(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	//abrindo db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//removendo a curtida e atualizando o contador juntos, repetir não muda nada
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		_, erro := transacao.Publicacoes.Descurtir(publicacaoID, usuarioID)
+		return erro
+	})
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// RepublicarPublicacao compartilha uma publicacao como ela é com os seguidores do usuário logado
+func RepublicarPublicacao(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	publicacaoID, erro := strconv.ParseUint(parametros["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//registrando a republicação, atualizando o contador e avisando o autor juntos, repetir não muda nada
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		nova, erro := transacao.Publicacoes.Republicar(publicacaoID, usuarioID)
+		if erro != nil || !nova {
+			return erro
+		}
+		return notificarAutor(transacao, publicacaoID, usuarioID, modelos.NotificacaoRepublicacao)
+	})
+	if erro == repositorios.ErroPublicacaoNaoEncontrada {
+		respostas.Erro(w, http.StatusNotFound, erroPublicacaoNaoEncontrada)
+		return
+	}
+	if erro == repositorios.ErroPublicacaoNaoRepublicavel {
+		respostas.Erro(w, http.StatusForbidden, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// DesfazerRepublicacao tira a republicação do usuário logado de uma publicacao
+func DesfazerRepublicacao(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	publicacaoID, erro := strconv.ParseUint(parametros["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarEscrita(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//removendo a republicação e atualizando o contador juntos, repetir não muda nada
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		_, erro := transacao.Publicacoes.DesfazerRepublicacao(publicacaoID, usuarioID)
+		return erro
+	})
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// BuscarCurtidasDaPublicacao traz os usuários que curtiram uma publicação
+func BuscarCurtidasDaPublicacao(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	publicacaoID, erro := strconv.ParseUint(parametros["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	pagina, limite, erro := extrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
@@ -291,10 +598,136 @@ func DescurtirPublicacao(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 	//usando metodos do repositorio para interagir com banco
 	repositorio := repositorios.NovoRepositorioDePublicacoes(db)
-	erro = repositorio.Descurtir(publicacaoID)
+	publicacao, erro := repositorio.BuscarPorID(publicacaoID, usuarioID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
-	respostas.JSON(w, http.StatusNoContent, nil)
+	if publicacao.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, erroPublicacaoNaoEncontrada)
+		return
+	}
+	usuarios, erro := repositorio.BuscarCurtidas(publicacaoID, usuarioID, pagina, limite)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, usuarios)
+}
+
+// PesquisarPublicacoes busca publicações pelo texto do título e do conteúdo, ordenadas por relevância e recência
+func PesquisarPublicacoes(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//lendo filtros da url (/publicacoes/busca?q=texto&autor=1&de=2024-01-01&ate=2024-02-01)
+	parametros := r.URL.Query()
+	consulta := busca.Consulta{Texto: strings.TrimSpace(parametros.Get("q"))}
+	if len(busca.Termos(consulta.Texto)) == 0 {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("o parâmetro q é obrigatório e precisa ter ao menos uma palavra"))
+		return
+	}
+	if autor := parametros.Get("autor"); autor != "" {
+		if consulta.AutorID, erro = strconv.ParseUint(autor, 10, 64); erro != nil {
+			respostas.Erro(w, http.StatusBadRequest, erro)
+			return
+		}
+	}
+	if de := parametros.Get("de"); de != "" {
+		if consulta.De, erro = time.ParseInLocation("2006-01-02", de, time.Local); erro != nil {
+			respostas.Erro(w, http.StatusBadRequest, erro)
+			return
+		}
+	}
+	if ate := parametros.Get("ate"); ate != "" {
+		if consulta.Ate, erro = time.ParseInLocation("2006-01-02", ate, time.Local); erro != nil {
+			respostas.Erro(w, http.StatusBadRequest, erro)
+			return
+		}
+		//a data final entra inteira na busca
+		consulta.Ate = consulta.Ate.AddDate(0, 0, 1)
+	}
+	consulta.Pagina, consulta.Limite, erro = extrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//buscando os ids no índice e depois as publicações de fato, só as que o usuário pode ver
+	repositorio := repositorios.NovoRepositorioDePublicacoes(db)
+	publicacoes, pontuacoes, erro := repositorio.Pesquisar(repositorios.NovoIndiceDeBusca(db), consulta, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	termos := busca.Termos(consulta.Texto)
+	resultadosDaBusca := make([]modelos.ResultadoDeBusca, 0, len(publicacoes))
+	for _, publicacao := range publicacoes {
+		resultadosDaBusca = append(resultadosDaBusca, modelos.ResultadoDeBusca{
+			Publicacao:      publicacao,
+			TituloDestacado: busca.Trecho(publicacao.Titulo, termos),
+			Trecho:          busca.Trecho(publicacao.Conteudo, termos),
+			Pontuacao:       pontuacoes[publicacao.ID],
+		})
+	}
+	respostas.JSON(w, http.StatusOK, resultadosDaBusca)
+}
+
+// BuscarHistoricoDaPublicacao traz todas as versões de uma publicação, da original até a atual
+func BuscarHistoricoDaPublicacao(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	//pegando parametro (url/{parametro})
+	parametros := mux.Vars(r)
+	publicacaoID, erro := strconv.ParseUint(parametros["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//o histórico de publicação deletada não aparece mais, igual à própria publicação
+	repositorio := repositorios.NovoRepositorioDePublicacoes(db)
+	publicacao, erro := repositorio.BuscarPorID(publicacaoID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if publicacao.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, erroPublicacaoNaoEncontrada)
+		return
+	}
+	revisoes, erro := repositorio.BuscarHistorico(publicacaoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, revisoes)
+}
+
+// notificarAutor avisa o autor da publicação que atorID interagiu com ela, sem escrever nada
+func notificarAutor(transacao repositorios.Transacao, publicacaoID, atorID uint64, tipo string) error {
+	publicacao, erro := transacao.Publicacoes.BuscarPorID(publicacaoID, 0)
+	if erro != nil || publicacao.ID == 0 {
+		return erro
+	}
+	return transacao.Notificacoes.Notificar(publicacao.AutorID, atorID, tipo, &publicacaoID, nil, "")
 }
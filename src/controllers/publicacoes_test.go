@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRepublicarPublicacaoQueNaoFoiRepublicada(t *testing.T) {
+	casos := []struct {
+		nome        string
+		visivel     bool
+		republicada bool
+		status      int
+	}{
+		{"publicação que não existe, foi deletada ou está escondida", false, false, http.StatusNotFound},
+		{"publicação visível que não pode ser republicada", true, false, http.StatusForbidden},
+		{"republicação repetida", true, true, http.StatusNoContent},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			//o insert com select não acha nada, e o que ele não achou é decidido pelas consultas seguintes
+			responderConsulta = func(query string, args []driver.Value) (respostaFalsa, error) {
+				switch {
+				case strings.HasPrefix(query, "insert ignore into republicacoes"):
+					return respostaFalsa{nadaAfetado: true}, nil
+				case strings.HasPrefix(query, "select count(*) > 0 from publicacoes p where p.id = ?"):
+					return respostaFalsa{colunas: 1, linhas: [][]driver.Value{{caso.visivel}}}, nil
+				case strings.HasPrefix(query, "select count(*) > 0 from republicacoes"):
+					return respostaFalsa{colunas: 1, linhas: [][]driver.Value{{caso.republicada}}}, nil
+				}
+				return respostaFalsa{}, fmt.Errorf("comando inesperado no banco falso: %s", query)
+			}
+			w := requisitar(t, RepublicarPublicacao, http.MethodPost, "/publicacoes/10/republicar", "", 4,
+				map[string]string{"publicacaoId": "10"})
+			if w.Code != caso.status {
+				t.Errorf("esperava status %d, veio %d: %s", caso.status, w.Code, w.Body)
+			}
+		})
+	}
+}
@@ -51,16 +51,76 @@ func CriarUsuario(w http.ResponseWriter, r *http.Request) {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
-
+	//a senha já com hash não volta na resposta
+	usuario.OcultarDadosPrivados(usuario.ID, false)
 	respostas.JSON(w, http.StatusCreated, usuario)
 }
 
 // BuscarUsuarios retorna dados de todos os usuários do db
 func BuscarUsuarios(w http.ResponseWriter, r *http.Request) {
 	//r.URL.Get("algumacoisa") pega o algumacoisa que está em url/usuarios?x=algumacoisa?y=slaoq
-	nomeOunick := strings.ToLower(r.URL.Query().Get("usuario"))
+	//maiúsculas e acentos são ignorados pelo repositório
+	nomeOunick := r.URL.Query().Get("usuario")
+	pagina, limite, erro := extrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
 	//abrindo db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarLeitura(usuarioLogadoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer db.Close()
+	//usando metodos do repositorio para interagir com banco
+	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
+	usuarios, erro := repositorio.Buscar(nomeOunick, usuarioLogadoID, pagina, limite)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if erro = ocultarDadosPrivados(repositorio, usuarios, usuarioLogadoID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	respostas.JSON(w, http.StatusOK, usuarios)
+}
+
+// ocultarDadosPrivados tira dos usuários o email e a senha que usuarioLogadoID não pode ver.
+// Toda rota que responde com usuários trazidos com email passa por aqui
+func ocultarDadosPrivados(repositorio *repositorios.Usuarios, usuarios []modelos.Usuario, usuarioLogadoID uint64) error {
+	admin, erro := repositorio.EhAdmin(usuarioLogadoID)
+	if erro != nil {
+		return erro
+	}
+	for i := range usuarios {
+		usuarios[i].OcultarDadosPrivados(usuarioLogadoID, admin)
+	}
+	return nil
+}
+
+// SugerirUsuarios completa o nick ou nome que o usuário está digitando
+func SugerirUsuarios(w http.ResponseWriter, r *http.Request) {
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	prefixo := r.URL.Query().Get("prefixo")
+	if strings.TrimSpace(prefixo) == "" {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("o prefixo é obrigatório e não pode estar em branco"))
+		return
+	}
+	//abrindo db
+	db, erro := banco.ConectarLeitura(usuarioLogadoID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
@@ -68,7 +128,7 @@ func BuscarUsuarios(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 	//usando metodos do repositorio para interagir com banco
 	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
-	usuarios, erro := repositorio.Buscar(nomeOunick)
+	usuarios, erro := repositorio.Sugerir(prefixo, usuarioLogadoID, 10)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
@@ -87,8 +147,14 @@ func BuscarUsuario(w http.ResponseWriter, r *http.Request) {
 		respostas.Erro(w, http.StatusBadRequest, erro)
 		return
 	}
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
 	//abrino db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarLeitura(usuarioLogadoID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
@@ -96,15 +162,36 @@ func BuscarUsuario(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 	//usando metodos do repositorio para interagir com banco
 	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
+	//com bloqueio entre os dois o perfil responde como se não existisse
+	bloqueado, erro := repositorio.Bloqueado(usuarioLogadoID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if bloqueado {
+		respostas.Erro(w, http.StatusNotFound, erroUsuarioNaoEncontrado)
+		return
+	}
 	usuario, erro := repositorio.BuscarPorID(usuarioID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
-	respostas.JSON(w, http.StatusOK, usuario)
+	//o usuário vem do cache igual para todos, a relação com quem está vendo é completada depois
+	usuarios := []modelos.Usuario{usuario}
+	if erro = repositorio.PreencherRelacoes(usuarios, usuarioLogadoID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if erro = ocultarDadosPrivados(repositorio, usuarios, usuarioLogadoID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	definirETag(w, usuario.Versao)
+	respostas.JSON(w, http.StatusOK, usuarios[0])
 }
 
-// AtualizarUsuario atualiza os dados de um usuario no db
+// AtualizarUsuario atualiza os dados e o perfil (bio, site, localização, pronomes, avatar e banner) de um usuario no db
 func AtualizarUsuario(w http.ResponseWriter, r *http.Request) {
 	//lendo parametros
 	parametros := mux.Vars(r)
@@ -142,20 +229,49 @@ func AtualizarUsuario(w http.ResponseWriter, r *http.Request) {
 		respostas.Erro(w, http.StatusBadRequest, erro)
 		return
 	}
+	//lendo a versão que o cliente tinha quando editou
+	usuario.Versao, erro = versaoDoIfMatch(r)
+	if erro != nil {
+		respostas.Erro(w, statusDoIfMatch(erro), erro)
+		return
+	}
 	//abrindo db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarEscrita(usuarioIDtoken)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 	defer db.Close()
+	//o avatar e o banner precisam ser imagens do próprio usuário enviadas em /anexos e fora de publicações
+	anexos := repositorios.NovoRepositorioDeAnexos(db)
+	for _, anexoID := range []*uint64{usuario.AvatarID, usuario.BannerID} {
+		if anexoID == nil {
+			continue
+		}
+		disponivel, erro := anexos.DisponivelParaPerfil(*anexoID, usuarioID)
+		if erro != nil {
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+			return
+		}
+		if !disponivel {
+			respostas.Erro(w, http.StatusUnprocessableEntity, repositorios.ErroAnexoIndisponivel)
+			return
+		}
+	}
 	//usando metodos do repositorio para interagir com banco
 	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
 	erro = repositorio.Atualizar(usuarioID, usuario)
+	if erro == repositorios.ErroVersaoDesatualizada {
+		respostas.Erro(w, http.StatusPreconditionFailed, erro)
+		return
+	}
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
+	if usuario.Versao != 0 {
+		definirETag(w, usuario.Versao+1)
+	}
 	respostas.JSON(w, http.StatusNoContent, nil)
 
 }
@@ -181,15 +297,16 @@ func DeletarUsuario(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	//abrindo db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarEscrita(usuarioIDtoken)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 	defer db.Close()
-	//usando metodos do repositorio para interagir com banco
-	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
-	erro = repositorio.Deletar(usuarioID)
+	//apagando e descontando o usuário dos contadores de quem ele seguia e de quem o seguia juntos
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		return transacao.Usuarios.Deletar(usuarioID)
+	})
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
@@ -219,15 +336,16 @@ func PararDeSeguirUsuario(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	//abrindo db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarEscrita(seguidorID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 	defer db.Close()
-	//usando metodos do repositorio para interagir com banco
-	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
-	erro = repositorio.PararDeSeguir(usuarioID, seguidorID)
+	//desfazendo a relação e os contadores juntos
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		return transacao.Usuarios.PararDeSeguir(usuarioID, seguidorID)
+	})
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
@@ -235,7 +353,8 @@ func PararDeSeguirUsuario(w http.ResponseWriter, r *http.Request) {
 	respostas.JSON(w, http.StatusNoContent, nil)
 }
 
-// SeguirUsuario é utilizada quando um usuario já está logado para ele seguir um outro usuário
+// SeguirUsuario é utilizada quando um usuario já está logado para ele seguir um outro usuário.
+// Se a conta for privada fica um pedido esperando aprovação, e a resposta é 202
 func SeguirUsuario(w http.ResponseWriter, r *http.Request) {
 	//Obtendo ID do token pra saber qual usuario está logado, o seguidor
 	seguidorID, erro := autenticacao.ExtrairUsuarioID(r)
@@ -256,19 +375,57 @@ func SeguirUsuario(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	//abrindo db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarEscrita(seguidorID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 	defer db.Close()
-	//usando metodos do repositorio para interagir com banco
-	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
-	erro = repositorio.Seguir(usuarioID, seguidorID)
+	//seguindo (ou pedindo para seguir) e avisando o usuário seguido juntos, repetir não gera outra notificação
+	pendente := false
+	erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		//com bloqueio entre os dois não dá para seguir, e o usuário responde como se não existisse
+		bloqueado, erro := transacao.Usuarios.Bloqueado(seguidorID, usuarioID)
+		if erro != nil {
+			return erro
+		}
+		usuario, erro := transacao.Usuarios.BuscarPorID(usuarioID)
+		if erro != nil {
+			return erro
+		}
+		if bloqueado || usuario.ID == 0 {
+			return erroUsuarioNaoEncontrado
+		}
+		if usuario.Privado {
+			segue, erro := transacao.Usuarios.Segue(seguidorID, usuarioID)
+			if erro != nil || segue {
+				return erro
+			}
+			pendente = true
+			novo, erro := transacao.Usuarios.PedirParaSeguir(usuarioID, seguidorID)
+			if erro != nil || !novo {
+				return erro
+			}
+			return transacao.Notificacoes.Notificar(usuarioID, seguidorID, modelos.NotificacaoPedidoParaSeguir, nil, nil, "")
+		}
+		novo, erro := transacao.Usuarios.Seguir(usuarioID, seguidorID)
+		if erro != nil || !novo {
+			return erro
+		}
+		return transacao.Notificacoes.Notificar(usuarioID, seguidorID, modelos.NotificacaoSeguidor, nil, nil, "")
+	})
+	if erro == erroUsuarioNaoEncontrado {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
+	if pendente {
+		respostas.JSON(w, http.StatusAccepted, nil)
+		return
+	}
 	respostas.JSON(w, http.StatusNoContent, nil)
 }
 
@@ -281,8 +438,14 @@ func BuscarSeguidores(w http.ResponseWriter, r *http.Request) {
 		respostas.Erro(w, http.StatusBadRequest, erro)
 		return
 	}
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
 	//abrindo db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarLeitura(usuarioLogadoID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
@@ -290,11 +453,34 @@ func BuscarSeguidores(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 	//usando metodos do repositorio para interagir com banco
 	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
-	seguidores, erro := repositorio.BuscarSeguidores(usuarioID)
+	//com bloqueio entre os dois o usuário responde como se não existisse
+	bloqueado, erro := repositorio.Bloqueado(usuarioLogadoID, usuarioID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
+	if bloqueado {
+		respostas.Erro(w, http.StatusNotFound, erroUsuarioNaoEncontrado)
+		return
+	}
+	podeVer, erro := repositorio.PodeVer(usuarioLogadoID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if !podeVer {
+		respostas.Erro(w, http.StatusForbidden, erroContaPrivada)
+		return
+	}
+	seguidores, erro := repositorio.BuscarSeguidores(usuarioID, usuarioLogadoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if erro = ocultarDadosPrivados(repositorio, seguidores, usuarioLogadoID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
 	respostas.JSON(w, http.StatusOK, seguidores)
 }
 
@@ -307,8 +493,14 @@ func BuscarSeguindo(w http.ResponseWriter, r *http.Request) {
 		respostas.Erro(w, http.StatusBadRequest, erro)
 		return
 	}
+	//Obtendo ID do token pra saber qual usuario está logado
+	usuarioLogadoID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
 	//abrindo db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarLeitura(usuarioLogadoID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
@@ -316,11 +508,34 @@ func BuscarSeguindo(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 	//usando metodos do repositorio para interagir com banco
 	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
-	seguindo, erro := repositorio.BuscarSeguindo(usuarioID)
+	//com bloqueio entre os dois o usuário responde como se não existisse
+	bloqueado, erro := repositorio.Bloqueado(usuarioLogadoID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if bloqueado {
+		respostas.Erro(w, http.StatusNotFound, erroUsuarioNaoEncontrado)
+		return
+	}
+	podeVer, erro := repositorio.PodeVer(usuarioLogadoID, usuarioID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
+	if !podeVer {
+		respostas.Erro(w, http.StatusForbidden, erroContaPrivada)
+		return
+	}
+	seguindo, erro := repositorio.BuscarSeguindo(usuarioID, usuarioLogadoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if erro = ocultarDadosPrivados(repositorio, seguindo, usuarioLogadoID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
 	respostas.JSON(w, http.StatusOK, seguindo)
 }
 
@@ -357,7 +572,7 @@ func AtualizarSenha(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	//abrindo db
-	db, erro := banco.Conectar()
+	db, erro := banco.ConectarEscrita(usuarioIDtoken)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
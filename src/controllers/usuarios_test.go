@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/config"
+	"api/src/modelos"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// usuarioFalso é uma linha da tabela usuarios do banco falso
+type usuarioFalso struct {
+	id           int64
+	email        string
+	emailPublico bool
+	admin        bool
+}
+
+// usuariosFalsos: 1 é o dono da conta vista, 2 é administrador, 3 deixou o email público e 4 é qualquer outro
+var usuariosFalsos = []usuarioFalso{
+	{id: 1, email: "dono@devbook.com"},
+	{id: 2, email: "admin@devbook.com", admin: true},
+	{id: 3, email: "publico@devbook.com", emailPublico: true},
+	{id: 4, email: "outro@devbook.com"},
+}
+
+// responderComUsuariosFalsos responde os comandos das rotas de usuários com usuariosFalsos.
+// Listas trazem todos, sem bloqueios e sem ninguém seguindo ninguém
+func responderComUsuariosFalsos(query string, args []driver.Value) (respostaFalsa, error) {
+	criadoEm := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	switch {
+	case strings.HasPrefix(query, "select admin from usuarios"):
+		for _, usuario := range usuariosFalsos {
+			if usuario.id == args[0] {
+				return respostaFalsa{colunas: 1, linhas: [][]driver.Value{{usuario.admin}}}, nil
+			}
+		}
+		return respostaFalsa{colunas: 1}, nil
+	case strings.HasPrefix(query, "select count(*) > 0 from bloqueios"):
+		return respostaFalsa{colunas: 1, linhas: [][]driver.Value{{false}}}, nil
+	case strings.HasPrefix(query, "select id = ? or not privado"):
+		return respostaFalsa{colunas: 1, linhas: [][]driver.Value{{true}}}, nil
+	case strings.HasPrefix(query, "select id, nome, nick, email, email_publico, criadoem, versao, privado,"):
+		//BuscarPorID
+		for _, usuario := range usuariosFalsos {
+			if usuario.id == args[0] {
+				return respostaFalsa{colunas: 17, linhas: [][]driver.Value{{
+					usuario.id, "Usuário", fmt.Sprint("usuario", usuario.id), usuario.email, usuario.emailPublico, criadoEm,
+					int64(1), false, "", "", "", "", nil, nil, int64(0), int64(0), int64(0),
+				}}}, nil
+			}
+		}
+		return respostaFalsa{colunas: 17}, nil
+	case strings.HasPrefix(query, "select u.id, u.nome, u.nick, u.email, u.email_publico, u.criadoem, u.bio,"):
+		//Buscar
+		resposta := respostaFalsa{colunas: 15}
+		for _, usuario := range usuariosFalsos {
+			resposta.linhas = append(resposta.linhas, []driver.Value{
+				usuario.id, "Usuário", fmt.Sprint("usuario", usuario.id), usuario.email, usuario.emailPublico, criadoEm,
+				"", "", "", "", nil, nil, int64(0), int64(0), int64(0),
+			})
+		}
+		return resposta, nil
+	case strings.HasPrefix(query, "select u.id, u.nome, u.nick, u.email, u.email_publico, u.criadoem, u.seguidores,"):
+		//BuscarSeguidores e BuscarSeguindo
+		resposta := respostaFalsa{colunas: 9}
+		for _, usuario := range usuariosFalsos {
+			resposta.linhas = append(resposta.linhas, []driver.Value{
+				usuario.id, "Usuário", fmt.Sprint("usuario", usuario.id), usuario.email, usuario.emailPublico, criadoEm,
+				int64(0), int64(0), int64(0),
+			})
+		}
+		return resposta, nil
+	case strings.Contains(query, "from seguidores"):
+		//PreencherRelacoes
+		return respostaFalsa{colunas: 2}, nil
+	case strings.HasPrefix(query, "insert into usuarios"):
+		return respostaFalsa{idNovo: 5}, nil
+	}
+	return respostaFalsa{}, fmt.Errorf("comando inesperado no banco falso: %s", query)
+}
+
+// requisitar chama handler como usuarioLogadoID, com parametros no lugar das variáveis da rota.
+// O banco falso responde com o responderConsulta que o teste escolheu
+func requisitar(t *testing.T, handler http.HandlerFunc, metodo, url, corpo string, usuarioLogadoID uint64, parametros map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	config.SecretKey = []byte("segredo dos testes")
+	r := httptest.NewRequest(metodo, url, strings.NewReader(corpo))
+	if usuarioLogadoID != 0 {
+		token, erro := autenticacao.CriarToken(usuarioLogadoID)
+		if erro != nil {
+			t.Fatal(erro)
+		}
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	r = mux.SetURLVars(r, parametros)
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// emailsVisiveis diz quais usuários de uma lista de usuariosFalsos quem está logado pode ver o email
+func emailsVisiveis(usuarioLogadoID uint64) map[uint64]bool {
+	return map[uint64]bool{1: usuarioLogadoID == 1 || usuarioLogadoID == 2, 2: usuarioLogadoID == 2, 3: true, 4: usuarioLogadoID == 4 || usuarioLogadoID == 2}
+}
+
+// conferirEmails confere que cada usuário da resposta tem o email só se quem está logado pode vê-lo, e nunca a senha
+func conferirEmails(t *testing.T, usuarios []modelos.Usuario, visiveis map[uint64]bool) {
+	t.Helper()
+	if len(usuarios) != len(usuariosFalsos) {
+		t.Fatalf("esperava %d usuários, vieram %d", len(usuariosFalsos), len(usuarios))
+	}
+	for i, usuario := range usuarios {
+		esperado := ""
+		if visiveis[usuario.ID] {
+			esperado = usuariosFalsos[i].email
+		}
+		if usuario.Email != esperado {
+			t.Errorf("usuário %d: esperava email %q, veio %q", usuario.ID, esperado, usuario.Email)
+		}
+		if usuario.Senha != "" {
+			t.Errorf("usuário %d: a senha veio na resposta", usuario.ID)
+		}
+	}
+}
+
+func TestBuscarUsuarioOcultaEmail(t *testing.T) {
+	responderConsulta = responderComUsuariosFalsos
+	casos := []struct {
+		nome            string
+		usuarioLogadoID uint64
+		usuarioID       string
+		email           string
+	}{
+		{"dono vê o próprio email", 1, "1", "dono@devbook.com"},
+		{"administrador vê o email", 2, "1", "dono@devbook.com"},
+		{"outro usuário não vê o email", 4, "1", ""},
+		{"email público aparece para todos", 4, "3", "publico@devbook.com"},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			w := requisitar(t, BuscarUsuario, http.MethodGet, "/usuarios/"+caso.usuarioID, "", caso.usuarioLogadoID,
+				map[string]string{"usuarioId": caso.usuarioID})
+			if w.Code != http.StatusOK {
+				t.Fatalf("esperava status 200, veio %d: %s", w.Code, w.Body)
+			}
+			var usuario modelos.Usuario
+			if erro := json.Unmarshal(w.Body.Bytes(), &usuario); erro != nil {
+				t.Fatal(erro)
+			}
+			if usuario.Email != caso.email {
+				t.Errorf("esperava email %q, veio %q", caso.email, usuario.Email)
+			}
+		})
+	}
+}
+
+func TestListasDeUsuariosOcultamEmail(t *testing.T) {
+	responderConsulta = responderComUsuariosFalsos
+	rotas := []struct {
+		nome    string
+		handler http.HandlerFunc
+		url     string
+	}{
+		{"pesquisa", BuscarUsuarios, "/usuarios?usuario=usuario"},
+		{"seguidores", BuscarSeguidores, "/usuarios/1/seguidores"},
+		{"seguindo", BuscarSeguindo, "/usuarios/1/seguindo"},
+	}
+	for _, rota := range rotas {
+		for _, usuarioLogadoID := range []uint64{1, 2, 4} {
+			t.Run(fmt.Sprintf("%s vista por %d", rota.nome, usuarioLogadoID), func(t *testing.T) {
+				w := requisitar(t, rota.handler, http.MethodGet, rota.url, "", usuarioLogadoID,
+					map[string]string{"usuarioId": "1"})
+				if w.Code != http.StatusOK {
+					t.Fatalf("esperava status 200, veio %d: %s", w.Code, w.Body)
+				}
+				var usuarios []modelos.Usuario
+				if erro := json.Unmarshal(w.Body.Bytes(), &usuarios); erro != nil {
+					t.Fatal(erro)
+				}
+				conferirEmails(t, usuarios, emailsVisiveis(usuarioLogadoID))
+			})
+		}
+	}
+}
+
+func TestCriarUsuarioDevolveEmailSemSenha(t *testing.T) {
+	responderConsulta = responderComUsuariosFalsos
+	w := requisitar(t, CriarUsuario, http.MethodPost, "/usuarios",
+		`{"nome":"Novo","nick":"novo","email":"novo@devbook.com","senha":"123456"}`, 0, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("esperava status 201, veio %d: %s", w.Code, w.Body)
+	}
+	var usuario modelos.Usuario
+	if erro := json.Unmarshal(w.Body.Bytes(), &usuario); erro != nil {
+		t.Fatal(erro)
+	}
+	if usuario.ID != 5 || usuario.Email != "novo@devbook.com" {
+		t.Errorf("esperava o usuário 5 com o próprio email, veio %d com %q", usuario.ID, usuario.Email)
+	}
+	if usuario.Senha != "" {
+		t.Error("a senha veio na resposta do cadastro")
+	}
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"api/src/config"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// erroIfMatchObrigatorio é retornado quando o modo estrito está ligado e a requisição não mandou If-Match
+var erroIfMatchObrigatorio = errors.New("o cabeçalho If-Match é obrigatório, busque o registro e envie o ETag recebido")
+
+// erroIfMatchInvalido é retornado quando o If-Match não tem uma versão que possa existir
+var erroIfMatchInvalido = errors.New("o cabeçalho If-Match não corresponde a nenhuma versão do registro")
+
+// definirETag coloca a versão do registro no cabeçalho ETag da resposta
+func definirETag(w http.ResponseWriter, versao uint64) {
+	w.Header().Set("ETag", fmt.Sprintf("\"%d\"", versao))
+}
+
+// versaoDoIfMatch lê a versão esperada no cabeçalho If-Match. Retorna 0 quando a escrita é incondicional
+func versaoDoIfMatch(r *http.Request) (uint64, error) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" {
+		if config.ExigirIfMatch {
+			return 0, erroIfMatchObrigatorio
+		}
+		return 0, nil
+	}
+	//* é o cliente dizendo explicitamente que qualquer versão serve
+	if ifMatch == "*" {
+		return 0, nil
+	}
+	//If-Match usa comparação forte, então ETag fraco (W/"x") nunca bate
+	if len(ifMatch) < 3 || !strings.HasPrefix(ifMatch, "\"") || !strings.HasSuffix(ifMatch, "\"") {
+		return 0, erroIfMatchInvalido
+	}
+	versao, erro := strconv.ParseUint(ifMatch[1:len(ifMatch)-1], 10, 64)
+	if erro != nil || versao == 0 {
+		return 0, erroIfMatchInvalido
+	}
+	return versao, nil
+}
+
+// statusDoIfMatch traduz os erros de versaoDoIfMatch para o status http correspondente
+func statusDoIfMatch(erro error) int {
+	if erro == erroIfMatchObrigatorio {
+		return http.StatusPreconditionRequired
+	}
+	return http.StatusPreconditionFailed
+}
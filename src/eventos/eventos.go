@@ -0,0 +1,217 @@
+package eventos
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	//tamanhoDaFila é quantos eventos uma conexão pode ter esperando para serem enviados antes de ser derrubada
+	tamanhoDaFila = 64
+	//tamanhoDoHistorico é quantos eventos recentes de cada usuário ficam guardados para quem reconectar
+	tamanhoDoHistorico = 100
+	//retencaoDoHistorico é por quanto tempo os eventos ficam guardados para quem reconectar
+	retencaoDoHistorico = 5 * time.Minute
+)
+
+// Evento é algo que aconteceu e precisa ser avisado para um usuário conectado
+type Evento struct {
+	//ID é "<época>-<sequência>": a época muda a cada vez que a api sobe, então ids de antes dela não são retomados
+	ID        string          `json:"id"`
+	Tipo      string          `json:"tipo"`
+	Dados     json.RawMessage `json:"dados"`
+	sequencia uint64
+	criadoEm  time.Time
+}
+
+// Assinatura é uma conexão recebendo os eventos de um usuário
+type Assinatura struct {
+	usuarioID uint64
+	eventos   chan Evento
+	//descartada é fechado quando a conexão não dá conta dos eventos e foi tirada do hub
+	descartada chan struct{}
+}
+
+// Eventos é por onde chegam os eventos da assinatura
+func (assinatura *Assinatura) Eventos() <-chan Evento {
+	return assinatura.eventos
+}
+
+// Descartada fecha quando a conexão ficou para trás e parou de receber eventos. O cliente pode reconectar
+// com o id do último evento que recebeu para retomar de onde parou
+func (assinatura *Assinatura) Descartada() <-chan struct{} {
+	return assinatura.descartada
+}
+
+// historico são os eventos recentes de um usuário
+type historico struct {
+	eventos []Evento
+	//descartadosAte é a sequência do evento mais novo que já saiu do histórico
+	descartadosAte uint64
+}
+
+// hub distribui os eventos publicados para as assinaturas dos usuários, dentro do próprio processo
+type hub struct {
+	mutex         sync.Mutex
+	epoca         int64
+	sequencia     uint64
+	assinaturas   map[uint64]map[*Assinatura]bool
+	historicos    map[uint64]*historico
+	ultimaLimpeza time.Time
+	//limpoAte é a sequência mais nova entre os históricos apagados inteiros pela limpeza
+	limpoAte uint64
+}
+
+var padrao = &hub{
+	epoca:       time.Now().UnixNano(),
+	assinaturas: map[uint64]map[*Assinatura]bool{},
+	historicos:  map[uint64]*historico{},
+}
+
+// Publicar envia um evento para todas as conexões de cada um dos usuários e guarda no histórico deles.
+// Nunca bloqueia: uma conexão com a fila cheia é descartada em vez de atrasar as outras
+func Publicar(usuariosIDs []uint64, tipo string, dados interface{}) error {
+	if len(usuariosIDs) == 0 {
+		return nil
+	}
+	conteudo, erro := json.Marshal(dados)
+	if erro != nil {
+		return erro
+	}
+	padrao.mutex.Lock()
+	defer padrao.mutex.Unlock()
+	padrao.sequencia++
+	agora := time.Now()
+	evento := Evento{
+		ID:        fmt.Sprintf("%d-%d", padrao.epoca, padrao.sequencia),
+		Tipo:      tipo,
+		Dados:     conteudo,
+		sequencia: padrao.sequencia,
+		criadoEm:  agora,
+	}
+	for _, usuarioID := range usuariosIDs {
+		padrao.guardar(usuarioID, evento)
+		for assinatura := range padrao.assinaturas[usuarioID] {
+			select {
+			case assinatura.eventos <- evento:
+			default:
+				padrao.remover(assinatura)
+				close(assinatura.descartada)
+			}
+		}
+	}
+	if agora.Sub(padrao.ultimaLimpeza) > retencaoDoHistorico {
+		padrao.limpar(agora)
+	}
+	return nil
+}
+
+// Assinar começa a receber os eventos de usuarioID. Se ultimoEventoID (o Last-Event-ID) for informado os eventos
+// depois dele que ainda estão no histórico também são retornados; o bool diz se algum pode ter se perdido
+// (histórico já descartado ou id de antes da api subir), quando o cliente deve recarregar pelas rotas normais
+func Assinar(usuarioID uint64, ultimoEventoID string) (*Assinatura, []Evento, bool) {
+	padrao.mutex.Lock()
+	defer padrao.mutex.Unlock()
+	assinatura := &Assinatura{
+		usuarioID:  usuarioID,
+		eventos:    make(chan Evento, tamanhoDaFila),
+		descartada: make(chan struct{}),
+	}
+	if padrao.assinaturas[usuarioID] == nil {
+		padrao.assinaturas[usuarioID] = map[*Assinatura]bool{}
+	}
+	padrao.assinaturas[usuarioID][assinatura] = true
+	if ultimoEventoID == "" {
+		return assinatura, nil, false
+	}
+	epoca, sequencia, valido := lerID(ultimoEventoID)
+	if !valido || epoca != padrao.epoca || sequencia > padrao.sequencia {
+		return assinatura, nil, true
+	}
+	historico := padrao.historicos[usuarioID]
+	if historico == nil {
+		//sem histórico não dá pra saber se o usuário tinha eventos no que a limpeza apagou
+		return assinatura, nil, sequencia < padrao.limpoAte
+	}
+	var perdidos []Evento
+	for _, evento := range historico.eventos {
+		if evento.sequencia > sequencia {
+			perdidos = append(perdidos, evento)
+		}
+	}
+	return assinatura, perdidos, sequencia < historico.descartadosAte
+}
+
+// Cancelar para de entregar eventos para a assinatura. Pode ser chamado mais de uma vez
+func Cancelar(assinatura *Assinatura) {
+	padrao.mutex.Lock()
+	defer padrao.mutex.Unlock()
+	padrao.remover(assinatura)
+}
+
+// remover tira a assinatura do hub, o mutex precisa estar travado
+func (hub *hub) remover(assinatura *Assinatura) {
+	assinaturas := hub.assinaturas[assinatura.usuarioID]
+	delete(assinaturas, assinatura)
+	if len(assinaturas) == 0 {
+		delete(hub.assinaturas, assinatura.usuarioID)
+	}
+}
+
+// guardar coloca o evento no histórico do usuário, o mutex precisa estar travado
+func (hub *hub) guardar(usuarioID uint64, evento Evento) {
+	historicoDoUsuario := hub.historicos[usuarioID]
+	if historicoDoUsuario == nil {
+		historicoDoUsuario = &historico{}
+		hub.historicos[usuarioID] = historicoDoUsuario
+	}
+	historicoDoUsuario.eventos = append(historicoDoUsuario.eventos, evento)
+	if excesso := len(historicoDoUsuario.eventos) - tamanhoDoHistorico; excesso > 0 {
+		historicoDoUsuario.descartadosAte = historicoDoUsuario.eventos[excesso-1].sequencia
+		historicoDoUsuario.eventos = append([]Evento(nil), historicoDoUsuario.eventos[excesso:]...)
+	}
+}
+
+// limpar tira dos históricos os eventos mais velhos que a retenção, o mutex precisa estar travado
+func (hub *hub) limpar(agora time.Time) {
+	hub.ultimaLimpeza = agora
+	for usuarioID, historicoDoUsuario := range hub.historicos {
+		antigos := 0
+		for antigos < len(historicoDoUsuario.eventos) && agora.Sub(historicoDoUsuario.eventos[antigos].criadoEm) > retencaoDoHistorico {
+			antigos++
+		}
+		if antigos == len(historicoDoUsuario.eventos) {
+			//sem eventos recentes o histórico inteiro sai, quem reconectar de antes disso é avisado por limpoAte
+			if ultimo := historicoDoUsuario.eventos[antigos-1].sequencia; ultimo > hub.limpoAte {
+				hub.limpoAte = ultimo
+			}
+			delete(hub.historicos, usuarioID)
+			continue
+		}
+		if antigos > 0 {
+			historicoDoUsuario.descartadosAte = historicoDoUsuario.eventos[antigos-1].sequencia
+			historicoDoUsuario.eventos = append([]Evento(nil), historicoDoUsuario.eventos[antigos:]...)
+		}
+	}
+}
+
+// lerID separa a época e a sequência de um id de evento
+func lerID(id string) (int64, uint64, bool) {
+	partes := strings.SplitN(id, "-", 2)
+	if len(partes) != 2 {
+		return 0, 0, false
+	}
+	epoca, erro := strconv.ParseInt(partes[0], 10, 64)
+	if erro != nil {
+		return 0, 0, false
+	}
+	sequencia, erro := strconv.ParseUint(partes[1], 10, 64)
+	if erro != nil {
+		return 0, 0, false
+	}
+	return epoca, sequencia, true
+}
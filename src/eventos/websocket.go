@@ -0,0 +1,177 @@
+package eventos
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// guidWebSocket é o valor fixo do protocolo (RFC 6455) usado para calcular o Sec-WebSocket-Accept
+const guidWebSocket = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// tipos de quadro do protocolo
+const (
+	quadroTexto  = 0x1
+	quadroFechar = 0x8
+	quadroPing   = 0x9
+	quadroPong   = 0xA
+)
+
+// tamanhoMaximoDeQuadro é o maior quadro aceito do cliente, que aqui só manda controle
+const tamanhoMaximoDeQuadro = 64 * 1024
+
+// ConexaoWebSocket é uma conexão WebSocket do lado do servidor, suficiente para empurrar eventos ao cliente
+type ConexaoWebSocket struct {
+	conexao net.Conn
+	leitor  *bufio.Reader
+	//escrita impede que o pong respondido pela leitura se misture com um evento sendo escrito
+	escrita sync.Mutex
+}
+
+// EhWebSocket diz se a requisição está pedindo para trocar para WebSocket
+func EhWebSocket(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
+
+// AceitarWebSocket faz o handshake e assume a conexão da requisição. Se o cliente ofereceu o subprotocolo protocolo
+// ele é escolhido na resposta, o navegador fecha a conexão quando oferece subprotocolos e nenhum é escolhido
+func AceitarWebSocket(w http.ResponseWriter, r *http.Request, protocolo string) (*ConexaoWebSocket, error) {
+	chave := r.Header.Get("Sec-WebSocket-Key")
+	if !EhWebSocket(r) || chave == "" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, errors.New("handshake de websocket inválido")
+	}
+	sequestrador, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("o servidor não permite assumir a conexão")
+	}
+	conexao, leitorEscritor, erro := sequestrador.Hijack()
+	if erro != nil {
+		return nil, erro
+	}
+	hash := sha1.Sum([]byte(chave + guidWebSocket))
+	resposta := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(hash[:]) + "\r\n"
+	for _, oferecido := range strings.Split(r.Header.Get("Sec-WebSocket-Protocol"), ",") {
+		if protocolo != "" && strings.TrimSpace(oferecido) == protocolo {
+			resposta += "Sec-WebSocket-Protocol: " + protocolo + "\r\n"
+			break
+		}
+	}
+	resposta += "\r\n"
+	if _, erro = conexao.Write([]byte(resposta)); erro != nil {
+		conexao.Close()
+		return nil, erro
+	}
+	return &ConexaoWebSocket{conexao: conexao, leitor: leitorEscritor.Reader}, nil
+}
+
+// EscreverTexto manda uma mensagem de texto
+func (ws *ConexaoWebSocket) EscreverTexto(mensagem []byte) error {
+	return ws.escreverQuadro(quadroTexto, mensagem)
+}
+
+// EscreverPing manda um ping, que o cliente deve responder com pong
+func (ws *ConexaoWebSocket) EscreverPing() error {
+	return ws.escreverQuadro(quadroPing, nil)
+}
+
+// Fechar manda o quadro de fechamento e encerra a conexão
+func (ws *ConexaoWebSocket) Fechar() error {
+	ws.escreverQuadro(quadroFechar, []byte{0x03, 0xE8}) //1000, fechamento normal
+	return ws.conexao.Close()
+}
+
+// Ler consome o que o cliente manda até a conexão fechar, respondendo pings. Mensagens de dados são ignoradas.
+// Retorna quando o cliente fecha, some por mais de espera sem mandar nada (nem pong) ou manda algo inválido
+func (ws *ConexaoWebSocket) Ler(espera time.Duration) error {
+	for {
+		ws.conexao.SetReadDeadline(time.Now().Add(espera))
+		tipo, conteudo, erro := ws.lerQuadro()
+		if erro != nil {
+			return erro
+		}
+		switch tipo {
+		case quadroFechar:
+			return io.EOF
+		case quadroPing:
+			if erro = ws.escreverQuadro(quadroPong, conteudo); erro != nil {
+				return erro
+			}
+		}
+	}
+}
+
+// escreverQuadro escreve um quadro final, sem máscara (servidores não mascaram)
+func (ws *ConexaoWebSocket) escreverQuadro(tipo byte, conteudo []byte) error {
+	ws.escrita.Lock()
+	defer ws.escrita.Unlock()
+	cabecalho := []byte{0x80 | tipo}
+	switch tamanho := len(conteudo); {
+	case tamanho < 126:
+		cabecalho = append(cabecalho, byte(tamanho))
+	case tamanho <= 0xFFFF:
+		cabecalho = append(cabecalho, 126, 0, 0)
+		binary.BigEndian.PutUint16(cabecalho[2:], uint16(tamanho))
+	default:
+		cabecalho = append(cabecalho, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(cabecalho[2:], uint64(tamanho))
+	}
+	ws.conexao.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, erro := ws.conexao.Write(append(cabecalho, conteudo...)); erro != nil {
+		return erro
+	}
+	return nil
+}
+
+// lerQuadro lê um quadro do cliente, que sempre vem mascarado
+func (ws *ConexaoWebSocket) lerQuadro() (byte, []byte, error) {
+	var cabecalho [2]byte
+	if _, erro := io.ReadFull(ws.leitor, cabecalho[:]); erro != nil {
+		return 0, nil, erro
+	}
+	tipo := cabecalho[0] & 0x0F
+	if cabecalho[1]&0x80 == 0 {
+		return 0, nil, errors.New("quadro do cliente sem máscara")
+	}
+	tamanho := uint64(cabecalho[1] & 0x7F)
+	switch tamanho {
+	case 126:
+		var estendido [2]byte
+		if _, erro := io.ReadFull(ws.leitor, estendido[:]); erro != nil {
+			return 0, nil, erro
+		}
+		tamanho = uint64(binary.BigEndian.Uint16(estendido[:]))
+	case 127:
+		var estendido [8]byte
+		if _, erro := io.ReadFull(ws.leitor, estendido[:]); erro != nil {
+			return 0, nil, erro
+		}
+		tamanho = binary.BigEndian.Uint64(estendido[:])
+	}
+	if tamanho > tamanhoMaximoDeQuadro {
+		return 0, nil, errors.New("quadro grande demais")
+	}
+	var mascara [4]byte
+	if _, erro := io.ReadFull(ws.leitor, mascara[:]); erro != nil {
+		return 0, nil, erro
+	}
+	conteudo := make([]byte, tamanho)
+	if _, erro := io.ReadFull(ws.leitor, conteudo); erro != nil {
+		return 0, nil, erro
+	}
+	for i := range conteudo {
+		conteudo[i] ^= mascara[i%4]
+	}
+	return tipo, conteudo, nil
+}
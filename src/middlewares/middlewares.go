@@ -26,3 +26,23 @@ func Autenticar(proximaFunc http.HandlerFunc) http.HandlerFunc {
 		proximaFunc(w, r)
 	}
 }
+
+// AceitarTokenDoNavegador passa para o cabeçalho Authorization o token que o navegador mandou na url ou no
+// Sec-WebSocket-Protocol, para as rotas que o EventSource e o WebSocket acessam. Vem antes do Logger para o token
+// da url não ir parar no log
+func AceitarTokenDoNavegador(proximaFunc http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			if token := autenticacao.ExtrairTokenDoNavegador(r); token != "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		parametros := r.URL.Query()
+		if parametros.Has("token") {
+			parametros.Del("token")
+			r.URL.RawQuery = parametros.Encode()
+			r.RequestURI = r.URL.RequestURI()
+		}
+		proximaFunc(w, r)
+	}
+}
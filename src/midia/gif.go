@@ -0,0 +1,63 @@
+package midia
+
+import "errors"
+
+var erroGIFTruncado = errors.New("gif truncado")
+
+// contarQuadrosDoGIF percorre os blocos do GIF sem decodificar nada, só para saber quantos quadros ele tem
+func contarQuadrosDoGIF(conteudo []byte) (int, error) {
+	//cabeçalho (6 bytes) e descritor da tela (7 bytes), cujo último campo de flags diz se há tabela de cores global
+	if len(conteudo) < 13 {
+		return 0, erroGIFTruncado
+	}
+	i := 13
+	if flags := conteudo[10]; flags&0x80 != 0 {
+		i += 3 << (flags&0x07 + 1)
+	}
+	quadros := 0
+	var erro error
+	for i < len(conteudo) {
+		switch conteudo[i] {
+		case 0x3B:
+			//fim do arquivo
+			return quadros, nil
+		case 0x21:
+			//extensão: introdutor, tipo e sub-blocos
+			if i, erro = pularSubBlocos(conteudo, i+2); erro != nil {
+				return 0, erro
+			}
+		case 0x2C:
+			//quadro: descritor de 10 bytes, tabela de cores local opcional, tamanho mínimo do código LZW e sub-blocos
+			quadros++
+			if i+10 > len(conteudo) {
+				return 0, erroGIFTruncado
+			}
+			flags := conteudo[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+			if i, erro = pularSubBlocos(conteudo, i+1); erro != nil {
+				return 0, erro
+			}
+		default:
+			return 0, errors.New("bloco de gif desconhecido")
+		}
+	}
+	return quadros, nil
+}
+
+// pularSubBlocos pula a sequência de sub-blocos que começa em i, cada um com o tamanho no primeiro byte e terminada por um de tamanho 0
+func pularSubBlocos(conteudo []byte, i int) (int, error) {
+	for {
+		if i >= len(conteudo) {
+			return 0, erroGIFTruncado
+		}
+		tamanho := int(conteudo[i])
+		i++
+		if tamanho == 0 {
+			return i, nil
+		}
+		i += tamanho
+	}
+}
@@ -0,0 +1,148 @@
+package midia
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	//ladoMaximoDaMiniatura é o maior lado, em pixels, das miniaturas geradas
+	ladoMaximoDaMiniatura = 320
+	//qualidadeJPEG é a qualidade usada ao gravar de novo as imagens JPEG
+	qualidadeJPEG = 90
+	//pixelsMaximosPorAnimacao limita a soma dos pixels de todos os quadros de um GIF,
+	//já que poucos bytes comprimidos podem virar muitos quadros grandes na memória
+	pixelsMaximosPorAnimacao = 100_000_000
+)
+
+// tipos de imagem aceitos
+const (
+	TipoJPEG = "image/jpeg"
+	TipoPNG  = "image/png"
+	TipoGIF  = "image/gif"
+)
+
+var (
+	// ErroTipoNaoPermitido é retornado quando o arquivo enviado não é uma imagem JPEG, PNG ou GIF
+	ErroTipoNaoPermitido = errors.New("o arquivo precisa ser uma imagem JPEG, PNG ou GIF")
+	// ErroImagemGrandeDemais é retornado quando a imagem passa da largura ou altura máxima
+	ErroImagemGrandeDemais = errors.New("a imagem passa do tamanho máximo permitido")
+)
+
+// Imagem é uma imagem enviada já limpa, pronta para ser guardada, junto da miniatura dela
+type Imagem struct {
+	Conteudo      []byte
+	Tipo          string
+	Largura       int
+	Altura        int
+	Miniatura     []byte
+	TipoMiniatura string
+}
+
+// Processar confere que conteudo é uma imagem aceita de no máximo dimensaoMaxima pixels de lado,
+// grava ela de novo para descartar metadados como o EXIF (que pode ter até a localização de quem tirou a foto)
+// e gera a miniatura. O tipo vem dos próprios bytes e não do que o cliente disse
+func Processar(conteudo []byte, dimensaoMaxima int) (Imagem, error) {
+	tipo := http.DetectContentType(conteudo)
+	if tipo != TipoJPEG && tipo != TipoPNG && tipo != TipoGIF {
+		return Imagem{}, ErroTipoNaoPermitido
+	}
+	//o cabeçalho diz o tamanho sem decodificar os pixels, assim uma imagem enorme é recusada antes de ocupar memória
+	configuracao, _, erro := image.DecodeConfig(bytes.NewReader(conteudo))
+	if erro != nil {
+		return Imagem{}, fmt.Errorf("imagem inválida: %w", erro)
+	}
+	if configuracao.Width <= 0 || configuracao.Height <= 0 {
+		return Imagem{}, errors.New("imagem inválida: sem pixels")
+	}
+	if configuracao.Width > dimensaoMaxima || configuracao.Height > dimensaoMaxima {
+		return Imagem{}, ErroImagemGrandeDemais
+	}
+	switch tipo {
+	case TipoJPEG:
+		return processarJPEG(conteudo)
+	case TipoPNG:
+		return processarPNG(conteudo)
+	default:
+		return processarGIF(conteudo, configuracao.Width*configuracao.Height)
+	}
+}
+
+// processarJPEG desvira a foto de acordo com a orientação do EXIF, já que o EXIF vai ser descartado
+func processarJPEG(conteudo []byte) (Imagem, error) {
+	original, erro := jpeg.Decode(bytes.NewReader(conteudo))
+	if erro != nil {
+		return Imagem{}, fmt.Errorf("imagem inválida: %w", erro)
+	}
+	imagem := orientar(original, orientacaoDoEXIF(conteudo))
+	var saida bytes.Buffer
+	if erro = jpeg.Encode(&saida, imagem, &jpeg.Options{Quality: qualidadeJPEG}); erro != nil {
+		return Imagem{}, erro
+	}
+	var miniatura bytes.Buffer
+	if erro = jpeg.Encode(&miniatura, reduzir(imagem, ladoMaximoDaMiniatura), &jpeg.Options{Quality: qualidadeJPEG}); erro != nil {
+		return Imagem{}, erro
+	}
+	return novaImagem(saida.Bytes(), TipoJPEG, imagem.Bounds(), miniatura.Bytes(), TipoJPEG), nil
+}
+
+// processarPNG grava só os pixels, deixando de fora os blocos de texto e EXIF que o PNG pode ter
+func processarPNG(conteudo []byte) (Imagem, error) {
+	imagem, erro := png.Decode(bytes.NewReader(conteudo))
+	if erro != nil {
+		return Imagem{}, fmt.Errorf("imagem inválida: %w", erro)
+	}
+	var saida bytes.Buffer
+	if erro = png.Encode(&saida, imagem); erro != nil {
+		return Imagem{}, erro
+	}
+	var miniatura bytes.Buffer
+	if erro = png.Encode(&miniatura, reduzir(imagem, ladoMaximoDaMiniatura)); erro != nil {
+		return Imagem{}, erro
+	}
+	return novaImagem(saida.Bytes(), TipoPNG, imagem.Bounds(), miniatura.Bytes(), TipoPNG), nil
+}
+
+// processarGIF mantém a animação, a miniatura é feita do primeiro quadro
+func processarGIF(conteudo []byte, pixelsPorQuadro int) (Imagem, error) {
+	quadros, erro := contarQuadrosDoGIF(conteudo)
+	if erro != nil {
+		return Imagem{}, fmt.Errorf("imagem inválida: %w", erro)
+	}
+	if quadros*pixelsPorQuadro > pixelsMaximosPorAnimacao {
+		return Imagem{}, ErroImagemGrandeDemais
+	}
+	animacao, erro := gif.DecodeAll(bytes.NewReader(conteudo))
+	if erro != nil {
+		return Imagem{}, fmt.Errorf("imagem inválida: %w", erro)
+	}
+	//os comentários e extensões de aplicativo do original não são gravados de novo
+	var saida bytes.Buffer
+	if erro = gif.EncodeAll(&saida, animacao); erro != nil {
+		return Imagem{}, erro
+	}
+	limites := image.Rect(0, 0, animacao.Config.Width, animacao.Config.Height)
+	var miniatura bytes.Buffer
+	if erro = png.Encode(&miniatura, reduzir(animacao.Image[0], ladoMaximoDaMiniatura)); erro != nil {
+		return Imagem{}, erro
+	}
+	return novaImagem(saida.Bytes(), TipoGIF, limites, miniatura.Bytes(), TipoPNG), nil
+}
+
+// novaImagem junta o resultado de um processamento
+func novaImagem(conteudo []byte, tipo string, limites image.Rectangle, miniatura []byte, tipoMiniatura string) Imagem {
+	return Imagem{
+		Conteudo:      conteudo,
+		Tipo:          tipo,
+		Largura:       limites.Dx(),
+		Altura:        limites.Dy(),
+		Miniatura:     miniatura,
+		TipoMiniatura: tipoMiniatura,
+	}
+}
@@ -0,0 +1,153 @@
+package midia
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"testing"
+)
+
+// exifComOrientacaoEGPS monta um segmento APP1 com EXIF little endian: a orientação na primeira lista de tags
+// e uma lista de GPS com a latitude e o datum, onde fica o texto localizacao para conferir que ele some
+func exifComOrientacaoEGPS(orientacao uint16, localizacao string) []byte {
+	ordem := binary.LittleEndian
+	tiff := []byte("II*\x00")
+	tiff = ordem.AppendUint32(tiff, 8)
+	//primeira lista em 8: 2 entradas de 12 bytes e o ponteiro para a próxima lista
+	inicioDoGPS := uint32(8 + 2 + 2*12 + 4)
+	tiff = ordem.AppendUint16(tiff, 2)
+	tiff = entradaTIFF(tiff, 0x0112, 3, 1, uint32(orientacao))
+	tiff = entradaTIFF(tiff, 0x8825, 4, 1, inicioDoGPS)
+	tiff = ordem.AppendUint32(tiff, 0)
+	//lista do GPS: LatitudeRef cabe na entrada, o datum fica depois da lista
+	texto := append([]byte(localizacao), 0)
+	tiff = ordem.AppendUint16(tiff, 2)
+	tiff = entradaTIFF(tiff, 0x0001, 2, 2, uint32('N'))
+	tiff = entradaTIFF(tiff, 0x0012, 2, uint32(len(texto)), inicioDoGPS+2+2*12+4)
+	tiff = ordem.AppendUint32(tiff, 0)
+	tiff = append(tiff, texto...)
+
+	segmento := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segmento)+2))
+	return append(app1, segmento...)
+}
+
+// entradaTIFF acrescenta uma entrada de 12 bytes de uma lista de tags TIFF, com o valor ou o deslocamento dele
+func entradaTIFF(tiff []byte, tag, tipo uint16, quantidade, valor uint32) []byte {
+	tiff = binary.LittleEndian.AppendUint16(tiff, tag)
+	tiff = binary.LittleEndian.AppendUint16(tiff, tipo)
+	tiff = binary.LittleEndian.AppendUint32(tiff, quantidade)
+	return binary.LittleEndian.AppendUint32(tiff, valor)
+}
+
+// fotoDeitada é um JPEG de 64x32 com a metade de cima vermelha e a de baixo azul, e o EXIF recebido logo depois do SOI
+func fotoDeitada(t *testing.T, exif []byte) []byte {
+	t.Helper()
+	imagem := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			cor := color.RGBA{R: 255, A: 255}
+			if y >= 16 {
+				cor = color.RGBA{B: 255, A: 255}
+			}
+			imagem.Set(x, y, cor)
+		}
+	}
+	var saida bytes.Buffer
+	if erro := jpeg.Encode(&saida, imagem, &jpeg.Options{Quality: 95}); erro != nil {
+		t.Fatal(erro)
+	}
+	conteudo := saida.Bytes()
+	return append(append(append([]byte{}, conteudo[:2]...), exif...), conteudo[2:]...)
+}
+
+func TestProcessarJPEGDescartaEXIFEDesvira(t *testing.T) {
+	conteudo := fotoDeitada(t, exifComOrientacaoEGPS(6, "LOCALIZACAO SECRETA"))
+	if orientacaoDoEXIF(conteudo) != 6 {
+		t.Fatal("o EXIF montado pelo teste não foi lido")
+	}
+	imagem, erro := Processar(conteudo, 4096)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if imagem.Tipo != TipoJPEG {
+		t.Errorf("esperava %s, veio %s", TipoJPEG, imagem.Tipo)
+	}
+	if bytes.Contains(imagem.Conteudo, []byte("Exif")) || bytes.Contains(imagem.Conteudo, []byte("LOCALIZACAO SECRETA")) {
+		t.Error("o EXIF com o GPS continuou na imagem gravada")
+	}
+	//orientação 6 é um quarto de volta no sentido horário: 64x32 vira 32x64 e a metade de baixo, azul, vai para a esquerda
+	if imagem.Largura != 32 || imagem.Altura != 64 {
+		t.Fatalf("esperava 32x64, veio %dx%d", imagem.Largura, imagem.Altura)
+	}
+	gravada, erro := jpeg.Decode(bytes.NewReader(imagem.Conteudo))
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if limites := gravada.Bounds(); limites.Dx() != 32 || limites.Dy() != 64 {
+		t.Fatalf("a imagem gravada tem %dx%d", limites.Dx(), limites.Dy())
+	}
+	if r, _, b, _ := gravada.At(4, 32).RGBA(); b < r {
+		t.Error("a esquerda da imagem desvirada deveria ser azul")
+	}
+	if r, _, b, _ := gravada.At(28, 32).RGBA(); r < b {
+		t.Error("a direita da imagem desvirada deveria ser vermelha")
+	}
+	if len(imagem.Miniatura) == 0 || imagem.TipoMiniatura != TipoJPEG {
+		t.Error("a miniatura do JPEG não foi gerada")
+	}
+}
+
+func TestProcessarRecusaTipoFalso(t *testing.T) {
+	//o cliente pode dizer que é image/png, mas o tipo vem dos bytes
+	conteudo := []byte("<html><body>isto não é uma imagem</body></html>")
+	if _, erro := Processar(conteudo, 4096); erro != ErroTipoNaoPermitido {
+		t.Errorf("esperava ErroTipoNaoPermitido, veio %v", erro)
+	}
+}
+
+// gifAnimado monta um GIF com a tela de largura x altura e quadros de 1x1, o menor possível para a tela declarada
+func gifAnimado(t *testing.T, largura, altura, quadros int) []byte {
+	t.Helper()
+	animacao := &gif.GIF{Config: image.Config{
+		ColorModel: color.Palette{color.Black, color.White},
+		Width:      largura,
+		Height:     altura,
+	}}
+	for i := 0; i < quadros; i++ {
+		animacao.Image = append(animacao.Image, image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.Black, color.White}))
+		animacao.Delay = append(animacao.Delay, 10)
+	}
+	var saida bytes.Buffer
+	if erro := gif.EncodeAll(&saida, animacao); erro != nil {
+		t.Fatal(erro)
+	}
+	return saida.Bytes()
+}
+
+func TestProcessarRecusaGIFGrandeDemais(t *testing.T) {
+	t.Run("lado maior que o máximo", func(t *testing.T) {
+		if _, erro := Processar(gifAnimado(t, 100, 10, 1), 50); erro != ErroImagemGrandeDemais {
+			t.Errorf("esperava ErroImagemGrandeDemais, veio %v", erro)
+		}
+	})
+	t.Run("quadros demais para o tamanho da tela", func(t *testing.T) {
+		//cada quadro de 4000x4000 conta 16 milhões de pixels, 7 deles passam do limite da animação
+		if _, erro := Processar(gifAnimado(t, 4000, 4000, 7), 4096); erro != ErroImagemGrandeDemais {
+			t.Errorf("esperava ErroImagemGrandeDemais, veio %v", erro)
+		}
+	})
+	t.Run("animação dentro do limite", func(t *testing.T) {
+		imagem, erro := Processar(gifAnimado(t, 40, 20, 3), 4096)
+		if erro != nil {
+			t.Fatal(erro)
+		}
+		if imagem.Tipo != TipoGIF || imagem.Largura != 40 || imagem.Altura != 20 {
+			t.Errorf("esperava um GIF de 40x20, veio %s de %dx%d", imagem.Tipo, imagem.Largura, imagem.Altura)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package midia
+
+import "image"
+
+// reduzir diminui a imagem para o maior lado ter ladoMaximo pixels, mantendo a proporção.
+// Cada pixel novo é a média dos pixels da área que ele cobre na original, o que evita o serrilhado
+// de só pular pixels. Imagens que já cabem voltam como estão
+func reduzir(imagem image.Image, ladoMaximo int) image.Image {
+	limites := imagem.Bounds()
+	largura, altura := limites.Dx(), limites.Dy()
+	if largura <= ladoMaximo && altura <= ladoMaximo {
+		return imagem
+	}
+	novaLargura, novaAltura := ladoMaximo, ladoMaximo
+	if largura >= altura {
+		novaAltura = max(1, altura*ladoMaximo/largura)
+	} else {
+		novaLargura = max(1, largura*ladoMaximo/altura)
+	}
+	origem := paraRGBA(imagem)
+	destino := image.NewRGBA(image.Rect(0, 0, novaLargura, novaAltura))
+	for y := 0; y < novaAltura; y++ {
+		y0, y1 := y*altura/novaAltura, (y+1)*altura/novaAltura
+		for x := 0; x < novaLargura; x++ {
+			x0, x1 := x*largura/novaLargura, (x+1)*largura/novaLargura
+			var soma [4]int
+			for linha := y0; linha < y1; linha++ {
+				i := origem.PixOffset(x0, linha)
+				for coluna := x0; coluna < x1; coluna++ {
+					soma[0] += int(origem.Pix[i])
+					soma[1] += int(origem.Pix[i+1])
+					soma[2] += int(origem.Pix[i+2])
+					soma[3] += int(origem.Pix[i+3])
+					i += 4
+				}
+			}
+			//RGBA guarda as cores já multiplicadas pela transparência, então a média simples é a certa
+			area := (x1 - x0) * (y1 - y0)
+			i := destino.PixOffset(x, y)
+			for canal := 0; canal < 4; canal++ {
+				destino.Pix[i+canal] = uint8(soma[canal] / area)
+			}
+		}
+	}
+	return destino
+}
@@ -0,0 +1,127 @@
+package midia
+
+import (
+	"encoding/binary"
+	"image"
+	"image/draw"
+)
+
+// tagDeOrientacao é a tag do EXIF que diz como a câmera estava virada
+const tagDeOrientacao = 0x0112
+
+// orientacaoDoEXIF lê a orientação guardada no EXIF de um JPEG, de 1 a 8. Sem EXIF ou com EXIF estragado é 1, a normal
+func orientacaoDoEXIF(conteudo []byte) int {
+	if len(conteudo) < 4 || conteudo[0] != 0xFF || conteudo[1] != 0xD8 {
+		return 1
+	}
+	//os segmentos do começo do arquivo são 0xFF, o marcador e o tamanho em 2 bytes, que inclui os próprios 2 bytes
+	for i := 2; i+4 <= len(conteudo); {
+		if conteudo[i] != 0xFF {
+			return 1
+		}
+		marcador := conteudo[i+1]
+		if marcador == 0xFF {
+			i++
+			continue
+		}
+		//depois do início dos dados da imagem não há mais metadados
+		if marcador == 0xDA || marcador == 0xD9 {
+			return 1
+		}
+		tamanho := int(binary.BigEndian.Uint16(conteudo[i+2:]))
+		if tamanho < 2 || i+2+tamanho > len(conteudo) {
+			return 1
+		}
+		segmento := conteudo[i+4 : i+2+tamanho]
+		if marcador == 0xE1 && len(segmento) >= 6 && string(segmento[:6]) == "Exif\x00\x00" {
+			return orientacaoDoTIFF(segmento[6:])
+		}
+		i += 2 + tamanho
+	}
+	return 1
+}
+
+// orientacaoDoTIFF procura a orientação na primeira lista de tags do bloco TIFF que fica dentro do EXIF
+func orientacaoDoTIFF(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var ordem binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		ordem = binary.LittleEndian
+	case "MM":
+		ordem = binary.BigEndian
+	default:
+		return 1
+	}
+	inicio := int(ordem.Uint32(tiff[4:]))
+	if inicio < 8 || inicio+2 > len(tiff) {
+		return 1
+	}
+	entradas := int(ordem.Uint16(tiff[inicio:]))
+	for n := 0; n < entradas; n++ {
+		entrada := inicio + 2 + n*12
+		if entrada+12 > len(tiff) {
+			return 1
+		}
+		if ordem.Uint16(tiff[entrada:]) == tagDeOrientacao {
+			if valor := int(ordem.Uint16(tiff[entrada+8:])); valor >= 1 && valor <= 8 {
+				return valor
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// orientar vira e espelha a imagem para ela ficar de pé de acordo com a orientação do EXIF
+func orientar(imagem image.Image, orientacao int) image.Image {
+	if orientacao <= 1 || orientacao > 8 {
+		return imagem
+	}
+	origem := paraRGBA(imagem)
+	largura, altura := origem.Rect.Dx(), origem.Rect.Dy()
+	novaLargura, novaAltura := largura, altura
+	//de 5 a 8 a imagem gira um quarto de volta, trocando largura e altura
+	if orientacao >= 5 {
+		novaLargura, novaAltura = altura, largura
+	}
+	destino := image.NewRGBA(image.Rect(0, 0, novaLargura, novaAltura))
+	for y := 0; y < novaAltura; y++ {
+		for x := 0; x < novaLargura; x++ {
+			//(origemX, origemY) é o pixel da imagem original que vai para (x, y)
+			var origemX, origemY int
+			switch orientacao {
+			case 2:
+				origemX, origemY = largura-1-x, y
+			case 3:
+				origemX, origemY = largura-1-x, altura-1-y
+			case 4:
+				origemX, origemY = x, altura-1-y
+			case 5:
+				origemX, origemY = y, x
+			case 6:
+				origemX, origemY = y, altura-1-x
+			case 7:
+				origemX, origemY = largura-1-y, altura-1-x
+			case 8:
+				origemX, origemY = largura-1-y, x
+			}
+			de, para := origem.PixOffset(origemX, origemY), destino.PixOffset(x, y)
+			copy(destino.Pix[para:para+4], origem.Pix[de:de+4])
+		}
+	}
+	return destino
+}
+
+// paraRGBA copia a imagem para RGBA começando em (0, 0), assim os pixels podem ser lidos direto
+func paraRGBA(imagem image.Image) *image.RGBA {
+	if rgba, ok := imagem.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	limites := imagem.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, limites.Dx(), limites.Dy()))
+	draw.Draw(rgba, rgba.Rect, imagem, limites.Min, draw.Src)
+	return rgba
+}
@@ -0,0 +1,40 @@
+package modelos
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaximoDeAnexosPorPublicacao é quantas imagens uma publicação pode ter
+const MaximoDeAnexosPorPublicacao = 4
+
+// Anexo é uma imagem enviada por um usuário para ser anexada a uma publicação dele
+type Anexo struct {
+	ID           uint64  `json:"id,omitempty"`
+	PublicacaoID *uint64 `json:"publicacaoId,omitempty"`
+	DonoID       uint64  `json:"donoId,omitempty"`
+	Tipo         string  `json:"tipo,omitempty"`
+	Largura      int     `json:"largura,omitempty"`
+	Altura       int     `json:"altura,omitempty"`
+	Tamanho      int64   `json:"tamanho,omitempty"`
+	//TextoAlternativo descreve a imagem para quem usa leitor de tela
+	TextoAlternativo string `json:"textoAlternativo"`
+	//URL e MiniaturaURL são as rotas da api que entregam a imagem e a miniatura
+	URL          string    `json:"url,omitempty"`
+	MiniaturaURL string    `json:"miniaturaUrl,omitempty"`
+	CriadoEm     time.Time `json:"criadoem,omitempty"`
+	//Chave e ChaveMiniatura são onde os arquivos ficam no armazenamento, não saem na api
+	Chave          string `json:"-"`
+	ChaveMiniatura string `json:"-"`
+}
+
+// Preparar irá validar e formatar o texto alternativo do anexo
+func (anexo *Anexo) Preparar() error {
+	anexo.TextoAlternativo = strings.TrimSpace(anexo.TextoAlternativo)
+	if utf8.RuneCountInString(anexo.TextoAlternativo) > 1000 {
+		return errors.New("o texto alternativo não pode passar de 1000 caracteres")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package modelos
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// tamanhoMaximoDeAudiencia é quantos membros uma audiência pode ter
+const tamanhoMaximoDeAudiencia = 500
+
+// Audiencia é uma lista com nome de usuários escolhidos pelo dono, usada para publicar só para eles
+type Audiencia struct {
+	ID   uint64 `json:"id,omitempty"`
+	Nome string `json:"nome,omitempty"`
+	//MembrosIDs é usado na criação e na atualização, com a lista completa de membros
+	MembrosIDs []uint64  `json:"membrosIds,omitempty"`
+	Membros    []Usuario `json:"membros,omitempty"`
+	CriadoEm   time.Time `json:"criadoem,omitempty"`
+}
+
+// Preparar irá validar e formatar os dados da audiência recebida, tirando o dono e os membros repetidos
+func (audiencia *Audiencia) Preparar(donoID uint64) error {
+	audiencia.Nome = strings.TrimSpace(audiencia.Nome)
+	if audiencia.Nome == "" {
+		return errors.New("o nome é obrigatório e não pode estar em branco")
+	}
+	if utf8.RuneCountInString(audiencia.Nome) > 50 {
+		return errors.New("o nome não pode passar de 50 caracteres")
+	}
+	vistos := map[uint64]bool{donoID: true}
+	var membros []uint64
+	for _, membroID := range audiencia.MembrosIDs {
+		if !vistos[membroID] {
+			vistos[membroID] = true
+			membros = append(membros, membroID)
+		}
+	}
+	if len(membros) > tamanhoMaximoDeAudiencia {
+		return errors.New("uma audiência pode ter no máximo 500 membros")
+	}
+	audiencia.MembrosIDs = membros
+	return nil
+}
@@ -0,0 +1,34 @@
+package modelos
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Comentario representa um comentário numa publicação ou uma resposta a outro comentário
+type Comentario struct {
+	ID           uint64     `json:"id,omitempty"`
+	PublicacaoID uint64     `json:"publicacaoId,omitempty"`
+	AutorID      uint64     `json:"autorId,omitempty"`
+	AutorNick    string     `json:"autorNick,omitempty"`
+	PaiID        *uint64    `json:"paiId,omitempty"`
+	Profundidade uint64     `json:"profundidade"`
+	Conteudo     string     `json:"conteudo"`
+	CriadoEm     time.Time  `json:"criadoem,omitempty"`
+	EditadoEm    *time.Time `json:"editadoEm,omitempty"`
+	//Deletado fica verdadeiro quando o comentário foi apagado mas continua na árvore por causa das respostas
+	Deletado bool `json:"deletado,omitempty"`
+}
+
+// Preparar irá validar e formatar os dados do comentário recebido
+func (comentario *Comentario) Preparar() error {
+	comentario.Conteudo = strings.TrimSpace(comentario.Conteudo)
+	if comentario.Conteudo == "" {
+		return errors.New("o conteúdo é obrigatório e não pode estar em branco")
+	}
+	if len([]rune(comentario.Conteudo)) > 300 {
+		return errors.New("o comentário pode ter no máximo 300 caracteres")
+	}
+	return nil
+}
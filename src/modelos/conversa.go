@@ -0,0 +1,95 @@
+package modelos
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// quem pode mandar mensagens diretas para um usuário
+const (
+	MensagensDeTodos    = "todos"
+	MensagensDeSeguidos = "seguidos"
+	MensagensDeNinguem  = "ninguem"
+)
+
+// MaximoDeParticipantes é o tamanho máximo de uma conversa em grupo, contando quem criou
+const MaximoDeParticipantes = 10
+
+// Conversa é uma troca de mensagens diretas entre dois usuários ou um grupo pequeno
+type Conversa struct {
+	ID            uint64    `json:"id,omitempty"`
+	Nome          string    `json:"nome,omitempty"`
+	Grupo         bool      `json:"grupo"`
+	Participantes []Usuario `json:"participantes,omitempty"`
+	//ParticipantesIDs é usado só na criação, com os outros usuários da conversa
+	ParticipantesIDs []uint64  `json:"participantesIds,omitempty"`
+	UltimaMensagem   *Mensagem `json:"ultimaMensagem,omitempty"`
+	//NaoLidas é quantas mensagens dos outros participantes o usuário logado ainda não leu
+	NaoLidas     uint64    `json:"naoLidas"`
+	CriadoEm     time.Time `json:"criadoem,omitempty"`
+	AtualizadoEm time.Time `json:"atualizadoEm,omitempty"`
+}
+
+// Conversas é uma página de conversas junto com quantas mensagens não lidas o usuário tem no total
+type Conversas struct {
+	NaoLidas  uint64     `json:"naoLidas"`
+	Conversas []Conversa `json:"conversas"`
+}
+
+// Mensagem é uma mensagem dentro de uma conversa
+type Mensagem struct {
+	ID            uint64    `json:"id,omitempty"`
+	ConversaID    uint64    `json:"conversaId,omitempty"`
+	RemetenteID   uint64    `json:"remetenteId,omitempty"`
+	RemetenteNick string    `json:"remetenteNick,omitempty"`
+	Conteudo      string    `json:"conteudo"`
+	CriadoEm      time.Time `json:"criadoem,omitempty"`
+	//Deletada fica verdadeiro quando o remetente apagou a mensagem, que continua na conversa sem o conteúdo
+	Deletada bool `json:"deletada,omitempty"`
+}
+
+// Preparar irá validar e formatar os dados da conversa recebida, sem contar quem está criando
+func (conversa *Conversa) Preparar(criadorID uint64) error {
+	conversa.Nome = strings.TrimSpace(conversa.Nome)
+	vistos := map[uint64]bool{criadorID: true}
+	var participantes []uint64
+	for _, participanteID := range conversa.ParticipantesIDs {
+		if !vistos[participanteID] {
+			vistos[participanteID] = true
+			participantes = append(participantes, participanteID)
+		}
+	}
+	conversa.ParticipantesIDs = participantes
+	if len(participantes) == 0 {
+		return errors.New("a conversa precisa de pelo menos um participante além de você")
+	}
+	if len(participantes)+1 > MaximoDeParticipantes {
+		return errors.New("a conversa pode ter no máximo 10 participantes")
+	}
+	conversa.Grupo = len(participantes) > 1
+	if !conversa.Grupo {
+		conversa.Nome = ""
+	}
+	if len([]rune(conversa.Nome)) > 50 {
+		return errors.New("o nome da conversa pode ter no máximo 50 caracteres")
+	}
+	return nil
+}
+
+// Preparar irá validar e formatar os dados da mensagem recebida
+func (mensagem *Mensagem) Preparar() error {
+	mensagem.Conteudo = strings.TrimSpace(mensagem.Conteudo)
+	if mensagem.Conteudo == "" {
+		return errors.New("o conteúdo é obrigatório e não pode estar em branco")
+	}
+	if len([]rune(mensagem.Conteudo)) > 1000 {
+		return errors.New("a mensagem pode ter no máximo 1000 caracteres")
+	}
+	return nil
+}
+
+// PermissaoDeMensagensValida diz se a permissão é uma das aceitas
+func PermissaoDeMensagensValida(permissao string) bool {
+	return permissao == MensagensDeTodos || permissao == MensagensDeSeguidos || permissao == MensagensDeNinguem
+}
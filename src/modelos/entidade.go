@@ -0,0 +1,136 @@
+package modelos
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Entidade é um trecho de uma publicação com significado próprio, como uma menção a um usuário ou uma hashtag.
+// Inicio e Fim são posições em caracteres (não bytes) dentro do campo (titulo ou conteudo), com Fim exclusivo
+type Entidade struct {
+	Tipo      string `json:"tipo"`
+	Campo     string `json:"campo"`
+	Texto     string `json:"texto"`
+	Inicio    int    `json:"inicio"`
+	Fim       int    `json:"fim"`
+	UsuarioID uint64 `json:"usuarioId,omitempty"`
+}
+
+const (
+	//TipoMencao é o tipo das entidades que marcam um @nick
+	TipoMencao = "mencao"
+	//TipoHashtag é o tipo das entidades que marcam uma #tag
+	TipoHashtag = "hashtag"
+	//tamanhoMaximoDeHashtag é o maior tamanho de tag guardado, tags maiores são ignoradas
+	tamanhoMaximoDeHashtag = 100
+)
+
+// extrairEntidades encontra as hashtags do título e as menções e hashtags do conteúdo, na ordem em que aparecem
+func extrairEntidades(titulo, conteudo string) []Entidade {
+	entidades := extrairHashtags("titulo", titulo)
+	doConteudo := append(extrairMencoes("conteudo", conteudo), extrairHashtags("conteudo", conteudo)...)
+	sort.Slice(doConteudo, func(i, j int) bool { return doConteudo[i].Inicio < doConteudo[j].Inicio })
+	return append(entidades, doConteudo...)
+}
+
+// extrairMencoes encontra os @nick do texto. O nick vai sem o @ em Texto, e o usuário ainda não é conhecido:
+// quem resolve os nicks para ids é o repositório
+func extrairMencoes(campo, texto string) []Entidade {
+	var mencoes []Entidade
+	caracteres := []rune(texto)
+	for i := 0; i < len(caracteres); i++ {
+		//o @ precisa começar uma palavra, senão um email viraria menção
+		if caracteres[i] != '@' || (i > 0 && caractereDeNick(caracteres[i-1])) {
+			continue
+		}
+		fim := i + 1
+		for fim < len(caracteres) && caractereDeNick(caracteres[fim]) {
+			fim++
+		}
+		//pontos no final são da frase, não do nick
+		for fim > i+1 && caracteres[fim-1] == '.' {
+			fim--
+		}
+		if fim == i+1 {
+			continue
+		}
+		mencoes = append(mencoes, Entidade{
+			Tipo:   TipoMencao,
+			Campo:  campo,
+			Texto:  string(caracteres[i+1 : fim]),
+			Inicio: i,
+			Fim:    fim,
+		})
+		i = fim - 1
+	}
+	return mencoes
+}
+
+// extrairHashtags encontra as #tags do texto. A tag vai sem o # e em minúsculas em Texto
+func extrairHashtags(campo, texto string) []Entidade {
+	var hashtags []Entidade
+	caracteres := []rune(texto)
+	for i := 0; i < len(caracteres); i++ {
+		if caracteres[i] != '#' || (i > 0 && caractereDeHashtag(caracteres[i-1])) {
+			continue
+		}
+		fim := i + 1
+		temLetra := false
+		for fim < len(caracteres) && caractereDeHashtag(caracteres[fim]) {
+			temLetra = temLetra || unicode.IsLetter(caracteres[fim])
+			fim++
+		}
+		//#1 ou #2024 são números, não assuntos
+		if temLetra && fim-i-1 <= tamanhoMaximoDeHashtag {
+			hashtags = append(hashtags, Entidade{
+				Tipo:   TipoHashtag,
+				Campo:  campo,
+				Texto:  NormalizarHashtag(string(caracteres[i+1 : fim])),
+				Inicio: i,
+				Fim:    fim,
+			})
+		}
+		i = fim - 1
+	}
+	return hashtags
+}
+
+// NormalizarHashtag deixa a tag no formato guardado: sem # e em minúsculas
+func NormalizarHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// Hashtags traz as tags das entidades, sem repetir
+func Hashtags(entidades []Entidade) []string {
+	vistas := map[string]bool{}
+	var tags []string
+	for _, entidade := range entidades {
+		if entidade.Tipo == TipoHashtag && !vistas[entidade.Texto] {
+			vistas[entidade.Texto] = true
+			tags = append(tags, entidade.Texto)
+		}
+	}
+	return tags
+}
+
+// NicksMencionados traz os nicks mencionados nas entidades, sem repetir
+func NicksMencionados(entidades []Entidade) []string {
+	vistos := map[string]bool{}
+	var nicks []string
+	for _, entidade := range entidades {
+		if entidade.Tipo == TipoMencao && !vistos[strings.ToLower(entidade.Texto)] {
+			vistos[strings.ToLower(entidade.Texto)] = true
+			nicks = append(nicks, entidade.Texto)
+		}
+	}
+	return nicks
+}
+
+func caractereDeHashtag(caractere rune) bool {
+	return unicode.IsLetter(caractere) || unicode.IsDigit(caractere) || caractere == '_'
+}
+
+func caractereDeNick(caractere rune) bool {
+	return unicode.IsLetter(caractere) || unicode.IsDigit(caractere) || caractere == '_' || caractere == '.' || caractere == '-'
+}
@@ -0,0 +1,10 @@
+package modelos
+
+// HashtagEmAlta é uma tag cujo uso está acelerando, com os números usados para chegar na pontuação
+type HashtagEmAlta struct {
+	Tag string `json:"tag"`
+	//Publicacoes é quantas publicações usaram a tag na janela atual, PublicacoesAntes na janela anterior
+	Publicacoes      uint64  `json:"publicacoes"`
+	PublicacoesAntes uint64  `json:"publicacoesAntes"`
+	Pontuacao        float64 `json:"pontuacao"`
+}
@@ -0,0 +1,40 @@
+package modelos
+
+import "time"
+
+// tipos de notificação
+const (
+	NotificacaoSeguidor     = "seguidor"
+	NotificacaoCurtida      = "curtida"
+	NotificacaoRepublicacao = "republicacao"
+	NotificacaoCitacao      = "citacao"
+	NotificacaoMencao       = "mencao"
+	NotificacaoComentario   = "comentario"
+	NotificacaoResposta     = "resposta"
+	//pedidos para seguir uma conta privada e a aprovação deles
+	NotificacaoPedidoParaSeguir = "pedido_para_seguir"
+	NotificacaoPedidoAprovado   = "pedido_aprovado"
+)
+
+// Notificacao avisa um usuário de algo que fizeram com ele ou com o que ele publicou.
+// Eventos repetidos enquanto a notificação não foi lida são agrupados nela: Quantidade diz
+// quantas pessoas diferentes os fizeram (como "12 pessoas curtiram sua publicação") e UltimoAtor quem chegou por último
+type Notificacao struct {
+	ID             uint64    `json:"id,omitempty"`
+	UsuarioID      uint64    `json:"usuarioId,omitempty"`
+	Tipo           string    `json:"tipo"`
+	PublicacaoID   *uint64   `json:"publicacaoId,omitempty"`
+	ComentarioID   *uint64   `json:"comentarioId,omitempty"`
+	Quantidade     uint64    `json:"quantidade"`
+	UltimoAtorID   uint64    `json:"ultimoAtorId"`
+	UltimoAtorNick string    `json:"ultimoAtorNick"`
+	Lida           bool      `json:"lida"`
+	CriadoEm       time.Time `json:"criadoem"`
+	AtualizadoEm   time.Time `json:"atualizadoEm"`
+}
+
+// Notificacoes é uma página de notificações junto com quantas ainda não foram lidas no total
+type Notificacoes struct {
+	NaoLidas     uint64        `json:"naoLidas"`
+	Notificacoes []Notificacao `json:"notificacoes"`
+}
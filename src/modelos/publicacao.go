@@ -6,15 +6,45 @@ import (
 	"time"
 )
 
+// quem pode ver uma publicação, além do autor
+const (
+	VisibilidadePublica    = "publico"
+	VisibilidadeSeguidores = "seguidores"
+	VisibilidadeAudiencia  = "audiencia"
+	VisibilidadeSomenteEu  = "somente_eu"
+)
+
 // Publicacao representa uma publicação feita por um usuário
 type Publicacao struct {
-	ID        uint64    `json:"id,omitempty"`
-	Titulo    string    `json:"titulo,omitempty"`
-	Conteudo  string    `json:"conteudo,omitempty"`
-	AutorID   uint64    `json:"autorId,omitempty"`
-	AutorNick string    `json:"autorNick,omitempty"`
-	Curtidas  uint64    `json:"curtidas"`
-	CriadoEm  time.Time `json:"criadoem,omitempty"`
+	ID            uint64 `json:"id,omitempty"`
+	Titulo        string `json:"titulo,omitempty"`
+	Conteudo      string `json:"conteudo,omitempty"`
+	AutorID       uint64 `json:"autorId,omitempty"`
+	AutorNick     string `json:"autorNick,omitempty"`
+	Curtidas      uint64 `json:"curtidas"`
+	Comentarios   uint64 `json:"comentarios"`
+	Republicacoes uint64 `json:"republicacoes"`
+	Citacoes      uint64 `json:"citacoes"`
+	//CitadaID é a publicação citada quando esta é uma citação (compartilhar com comentário)
+	CitadaID *uint64 `json:"citadaId,omitempty"`
+	//Visibilidade diz quem pode ver a publicação, e AudienciaID é a lista de quem pode quando ela é "audiencia"
+	Visibilidade string     `json:"visibilidade,omitempty"`
+	AudienciaID  *uint64    `json:"audienciaId,omitempty"`
+	CriadoEm     time.Time  `json:"criadoem,omitempty"`
+	Versao       uint64     `json:"versao,omitempty"`
+	EditadoEm    *time.Time `json:"editadoEm,omitempty"`
+	//AnexosIDs são as imagens já enviadas em /anexos que entram na publicação ao criá-la, na ordem em que aparecem
+	AnexosIDs []uint64 `json:"anexosIds,omitempty"`
+	Anexos    []Anexo  `json:"anexos,omitempty"`
+	//Entidades marca as menções encontradas no conteúdo e as hashtags do título e do conteúdo
+	Entidades []Entidade `json:"entidades,omitempty"`
+	//CurtidaPorMim diz se o usuário logado curtiu a publicação
+	CurtidaPorMim bool `json:"curtidaPorMim"`
+	//RepublicadaPorMim diz se o usuário logado republicou a publicação
+	RepublicadaPorMim bool `json:"republicadaPorMim"`
+	//RepublicadaPorID e RepublicadaPorNick dizem, no feed, quem republicou a publicação para o usuário logado
+	RepublicadaPorID   uint64 `json:"republicadaPorId,omitempty"`
+	RepublicadaPorNick string `json:"republicadaPorNick,omitempty"`
 }
 
 // Preparar irá validar e formatar os dados da publicacao recebidos
@@ -23,6 +53,7 @@ func (publicacao *Publicacao) Preparar() error {
 		return erro
 	}
 	publicacao.formatar()
+	publicacao.Entidades = extrairEntidades(publicacao.Titulo, publicacao.Conteudo)
 	return nil
 }
 
@@ -33,6 +64,33 @@ func (publicacao *Publicacao) validar() error {
 	if publicacao.Conteudo == "" {
 		return errors.New("o conteúdo é obrigatório e não pode estar em branco")
 	}
+	switch publicacao.Visibilidade {
+	case "":
+		publicacao.Visibilidade = VisibilidadePublica
+	case VisibilidadePublica, VisibilidadeSeguidores, VisibilidadeSomenteEu:
+	case VisibilidadeAudiencia:
+		if publicacao.AudienciaID == nil {
+			return errors.New("a audiência é obrigatória quando a visibilidade é audiencia")
+		}
+	default:
+		return errors.New("a visibilidade precisa ser publico, seguidores, audiencia ou somente_eu")
+	}
+	//a audiência só vale para a visibilidade audiencia
+	if publicacao.Visibilidade != VisibilidadeAudiencia {
+		publicacao.AudienciaID = nil
+	}
+	vistos := map[uint64]bool{}
+	var anexos []uint64
+	for _, anexoID := range publicacao.AnexosIDs {
+		if !vistos[anexoID] {
+			vistos[anexoID] = true
+			anexos = append(anexos, anexoID)
+		}
+	}
+	if len(anexos) > MaximoDeAnexosPorPublicacao {
+		return errors.New("uma publicação pode ter no máximo 4 imagens")
+	}
+	publicacao.AnexosIDs = anexos
 	return nil
 }
 
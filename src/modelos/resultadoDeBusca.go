@@ -0,0 +1,9 @@
+package modelos
+
+// ResultadoDeBusca é uma publicação encontrada na busca de texto, com os termos destacados
+type ResultadoDeBusca struct {
+	Publicacao      Publicacao `json:"publicacao"`
+	TituloDestacado string     `json:"tituloDestacado"`
+	Trecho          string     `json:"trecho"`
+	Pontuacao       float64    `json:"pontuacao"`
+}
@@ -0,0 +1,12 @@
+package modelos
+
+import "time"
+
+// Revisao é uma versão do título e conteúdo de uma publicação, guardada a cada criação ou edição
+type Revisao struct {
+	ID           uint64    `json:"id"`
+	PublicacaoID uint64    `json:"publicacaoId"`
+	Titulo       string    `json:"titulo"`
+	Conteudo     string    `json:"conteudo"`
+	CriadoEm     time.Time `json:"criadoem"`
+}
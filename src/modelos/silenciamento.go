@@ -0,0 +1,73 @@
+package modelos
+
+import (
+	"api/src/busca"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// tamanhoMaximoDePalavraSilenciada é o tamanho máximo, em caracteres, de uma palavra ou frase silenciada
+const tamanhoMaximoDePalavraSilenciada = 100
+
+// ContaSilenciada é um usuário silenciado pelo usuário logado. Quem é silenciado não fica sabendo
+type ContaSilenciada struct {
+	UsuarioID uint64 `json:"usuarioId,omitempty"`
+	Nick      string `json:"nick,omitempty"`
+	//Ate é quando o silenciamento acaba sozinho, sem ele dura até ser desfeito
+	Ate      *time.Time `json:"ate,omitempty"`
+	CriadoEm time.Time  `json:"criadoem,omitempty"`
+}
+
+// PalavraSilenciada é uma palavra ou frase que o usuário logado não quer ver no feed e nas notificações
+type PalavraSilenciada struct {
+	ID      uint64 `json:"id,omitempty"`
+	Palavra string `json:"palavra,omitempty"`
+	//Ate é quando o silenciamento acaba sozinho, sem ele dura até ser desfeito
+	Ate      *time.Time `json:"ate,omitempty"`
+	CriadoEm time.Time  `json:"criadoem,omitempty"`
+}
+
+// Preparar irá validar os dados do silenciamento de conta recebido
+func (conta *ContaSilenciada) Preparar() error {
+	return validarFimDoSilenciamento(conta.Ate)
+}
+
+// Preparar irá validar e formatar os dados da palavra silenciada recebida
+func (palavra *PalavraSilenciada) Preparar() error {
+	palavra.Palavra = strings.TrimSpace(palavra.Palavra)
+	if len(busca.Termos(palavra.Palavra)) == 0 {
+		return errors.New("a palavra é obrigatória e precisa ter ao menos uma letra ou número")
+	}
+	if utf8.RuneCountInString(palavra.Palavra) > tamanhoMaximoDePalavraSilenciada {
+		return errors.New("a palavra não pode passar de 100 caracteres")
+	}
+	return validarFimDoSilenciamento(palavra.Ate)
+}
+
+func validarFimDoSilenciamento(ate *time.Time) error {
+	if ate != nil && !ate.After(time.Now()) {
+		return errors.New("o fim do silenciamento precisa estar no futuro")
+	}
+	return nil
+}
+
+// Silenciamentos é o que um usuário silenciou e ainda está valendo, usado para filtrar o que chega até ele
+type Silenciamentos struct {
+	Contas   map[uint64]bool
+	Palavras []string
+}
+
+// Silencia diz se algo feito por autorID com o texto recebido está silenciado
+func (silenciamentos Silenciamentos) Silencia(autorID uint64, texto string) bool {
+	if silenciamentos.Contas[autorID] {
+		return true
+	}
+	for _, palavra := range silenciamentos.Palavras {
+		if busca.ContemFrase(texto, palavra) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,8 +3,10 @@ package modelos
 import (
 	"api/src/seguranca"
 	"errors"
+	"net/url"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/badoux/checkmail"
 )
@@ -17,6 +19,31 @@ type Usuario struct {
 	Email    string    `json:"email,omitempty"`
 	Senha    string    `json:"senha,omitempty"`
 	CriadoEm time.Time `json:"criadoem,omitempty"`
+	Versao   uint64    `json:"versao,omitempty"`
+	//Privado diz se só os seguidores aprovados veem as publicações, os seguidores e quem o usuário segue
+	Privado bool `json:"privado"`
+	//EmailPublico deixa qualquer um ver o email, que por padrão só o dono da conta e os administradores veem
+	EmailPublico bool `json:"emailPublico"`
+	//dados do perfil, todos opcionais
+	Bio         string `json:"bio,omitempty"`
+	Site        string `json:"site,omitempty"`
+	Localizacao string `json:"localizacao,omitempty"`
+	Pronomes    string `json:"pronomes,omitempty"`
+	//AvatarID e BannerID são imagens enviadas em /anexos, as urls são preenchidas na leitura
+	AvatarID           *uint64 `json:"avatarId,omitempty"`
+	AvatarURL          string  `json:"avatarUrl,omitempty"`
+	AvatarMiniaturaURL string  `json:"avatarMiniaturaUrl,omitempty"`
+	BannerID           *uint64 `json:"bannerId,omitempty"`
+	BannerURL          string  `json:"bannerUrl,omitempty"`
+	//Seguidores, Seguindo e Publicacoes são contadores guardados no usuário e atualizados a cada mudança
+	Seguidores  uint64 `json:"seguidores,omitempty"`
+	Seguindo    uint64 `json:"seguindo,omitempty"`
+	Publicacoes uint64 `json:"publicacoes,omitempty"`
+	//SeguidoPorMim, MeSegue e SeguidoresEmComum são a relação com o usuário logado. SeguidoresEmComum é
+	//quantos dos que o usuário logado segue também seguem este
+	SeguidoPorMim     bool   `json:"seguidoPorMim,omitempty"`
+	MeSegue           bool   `json:"meSegue,omitempty"`
+	SeguidoresEmComum uint64 `json:"seguidoresEmComum,omitempty"`
 }
 
 // Preparar irá validar e formatar os dados do usuário recebido
@@ -46,14 +73,60 @@ func (usuario *Usuario) validar(momento string) error {
 	if momento == "cadastro" && usuario.Senha == "" {
 		return errors.New("a senha é obrigatório e não pode estar em branco")
 	}
+	if utf8.RuneCountInString(strings.TrimSpace(usuario.Bio)) > 160 {
+		return errors.New("a bio não pode passar de 160 caracteres")
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(usuario.Localizacao)) > 30 {
+		return errors.New("a localização não pode passar de 30 caracteres")
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(usuario.Pronomes)) > 30 {
+		return errors.New("os pronomes não podem passar de 30 caracteres")
+	}
 
 	return nil
 }
 
+// formatarSite confere que o site é um endereço http ou https e o devolve normalizado.
+// Sem esquema o site é tratado como https, e qualquer outro esquema (javascript:, data:...) é recusado
+// porque o endereço vai virar um link no perfil. Com https:// na frente, "javascript:..." vira um host inválido
+func formatarSite(site string) (string, error) {
+	site = strings.TrimSpace(site)
+	if site == "" {
+		return "", nil
+	}
+	if !strings.Contains(site, "://") {
+		site = "https://" + site
+	}
+	endereco, erro := url.Parse(site)
+	if erro != nil {
+		return "", errors.New("o site inserido é inválido")
+	}
+	endereco.Scheme = strings.ToLower(endereco.Scheme)
+	if endereco.Scheme != "http" && endereco.Scheme != "https" {
+		return "", errors.New("o site precisa ser um endereço http ou https")
+	}
+	if endereco.Host == "" || endereco.User != nil {
+		return "", errors.New("o site inserido é inválido")
+	}
+	site = endereco.String()
+	if len(site) > 100 {
+		return "", errors.New("o site não pode passar de 100 caracteres")
+	}
+	return site, nil
+}
+
 func (usuario *Usuario) formatar(momento string) error {
 	usuario.Nome = strings.TrimSpace(usuario.Nome)
 	usuario.Nick = strings.TrimSpace(usuario.Nick)
 	usuario.Email = strings.TrimSpace(usuario.Email)
+	usuario.Bio = strings.TrimSpace(usuario.Bio)
+	usuario.Localizacao = strings.TrimSpace(usuario.Localizacao)
+	usuario.Pronomes = strings.TrimSpace(usuario.Pronomes)
+	site, erro := formatarSite(usuario.Site)
+	if erro != nil {
+		return erro
+	}
+	usuario.Site = site
 	if momento == "cadastro" {
 		senhaHash, erro := seguranca.Hash(usuario.Senha)
 		if erro != nil {
@@ -63,3 +136,13 @@ func (usuario *Usuario) formatar(momento string) error {
 	}
 	return nil
 }
+
+// OcultarDadosPrivados apaga do usuário o que usuarioLogadoID não pode ver antes dele ir na resposta. A senha nunca sai,
+// e o email só aparece para o próprio dono, para administradores ou se o dono deixou ele público
+func (usuario *Usuario) OcultarDadosPrivados(usuarioLogadoID uint64, admin bool) {
+	usuario.Senha = ""
+	if usuario.ID == usuarioLogadoID || admin || usuario.EmailPublico {
+		return
+	}
+	usuario.Email = ""
+}
@@ -0,0 +1,192 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErroAnexoIndisponivel é retornado ao usar numa publicação ou no perfil um anexo que não existe,
+// é de outro usuário ou já está em uso
+var ErroAnexoIndisponivel = errors.New("anexo não encontrado ou já usado em outro lugar")
+
+// colunasDeAnexo são as colunas lidas em toda busca de anexos, na ordem em que escanearAnexo espera
+const colunasDeAnexo = "id, publicacao_id, dono_id, tipo, largura, altura, tamanho, texto_alternativo, chave, chave_miniatura, criadoEm"
+
+// usadoNoPerfil é a condição de um anexo ser o avatar ou o banner de alguém
+const usadoNoPerfil = "exists (select 1 from usuarios u where u.avatar_id = anexos.id or u.banner_id = anexos.id)"
+
+// Anexos representa o repositório das imagens anexadas às publicações
+type Anexos struct {
+	db executor
+}
+
+// NovoRepositorioDeAnexos cria um repositorio de anexos
+func NovoRepositorioDeAnexos(db *sql.DB) *Anexos {
+	return &Anexos{db}
+}
+
+// Criar insere um anexo ainda sem publicação, com os arquivos já guardados no armazenamento
+func (repositorio Anexos) Criar(anexo modelos.Anexo) (uint64, error) {
+	statement, erro := repositorio.db.Prepare(
+		"insert into anexos (dono_id, tipo, largura, altura, tamanho, texto_alternativo, chave, chave_miniatura) values (?,?,?,?,?,?,?,?)")
+	if erro != nil {
+		return 0, erro
+	}
+	defer statement.Close()
+	resultado, erro := statement.Exec(anexo.DonoID, anexo.Tipo, anexo.Largura, anexo.Altura, anexo.Tamanho,
+		anexo.TextoAlternativo, anexo.Chave, anexo.ChaveMiniatura)
+	if erro != nil {
+		return 0, erro
+	}
+	anexoID, erro := resultado.LastInsertId()
+	if erro != nil {
+		return 0, erro
+	}
+	return uint64(anexoID), nil
+}
+
+// BuscarPorID traz um anexo pelo id, vazio se não existir
+func (repositorio Anexos) BuscarPorID(anexoID uint64) (modelos.Anexo, error) {
+	linhas, erro := repositorio.db.Query("select "+colunasDeAnexo+" from anexos where id = ?", anexoID)
+	if erro != nil {
+		return modelos.Anexo{}, erro
+	}
+	defer linhas.Close()
+	if !linhas.Next() {
+		return modelos.Anexo{}, linhas.Err()
+	}
+	return escanearAnexo(linhas)
+}
+
+// AtualizarTextoAlternativo troca o texto alternativo do anexo e tira do cache a publicação onde ele está
+func (repositorio Anexos) AtualizarTextoAlternativo(anexo modelos.Anexo) error {
+	if _, erro := repositorio.db.Exec("update anexos set texto_alternativo = ? where id = ?", anexo.TextoAlternativo, anexo.ID); erro != nil {
+		return erro
+	}
+	if anexo.PublicacaoID != nil {
+		invalidarCache(repositorio.db, chavePublicacao(*anexo.PublicacaoID))
+		invalidarFeeds(repositorio.db)
+	}
+	return nil
+}
+
+// vincular coloca os anexos na publicação, na ordem recebida. Todos precisam ser de donoID e estar livres,
+// caso contrário retorna ErroAnexoIndisponivel, então deve ser chamado dentro de uma transação
+func (repositorio Anexos) vincular(publicacaoID, donoID uint64, anexosIDs []uint64) error {
+	for posicao, anexoID := range anexosIDs {
+		resultado, erro := repositorio.db.Exec(
+			"update anexos set publicacao_id = ?, posicao = ? where id = ? and dono_id = ? and publicacao_id is null and not "+usadoNoPerfil,
+			publicacaoID, posicao, anexoID, donoID)
+		if erro != nil {
+			return erro
+		}
+		linhasAfetadas, erro := resultado.RowsAffected()
+		if erro != nil {
+			return erro
+		}
+		if linhasAfetadas == 0 {
+			return ErroAnexoIndisponivel
+		}
+	}
+	return nil
+}
+
+// DisponivelParaPerfil diz se o anexo é de donoID e não está numa publicação, podendo virar avatar ou banner
+func (repositorio Anexos) DisponivelParaPerfil(anexoID, donoID uint64) (bool, error) {
+	var disponivel bool
+	erro := repositorio.db.QueryRow(
+		"select count(*) > 0 from anexos where id = ? and dono_id = ? and publicacao_id is null", anexoID, donoID).Scan(&disponivel)
+	return disponivel, erro
+}
+
+// UsadoNoPerfil diz se o anexo é o avatar ou o banner do dono, que qualquer um pode ver
+func (repositorio Anexos) UsadoNoPerfil(anexoID uint64) (bool, error) {
+	var usado bool
+	erro := repositorio.db.QueryRow("select "+usadoNoPerfil+" from anexos where id = ?", anexoID).Scan(&usado)
+	if erro == sql.ErrNoRows {
+		return false, nil
+	}
+	return usado, erro
+}
+
+// preencherPublicacoes coloca em cada publicação os anexos dela. Os anexos são iguais para todo mundo,
+// então isso é feito antes das publicações irem para o cache
+func (repositorio Anexos) preencherPublicacoes(publicacoes []modelos.Publicacao) error {
+	if len(publicacoes) == 0 {
+		return nil
+	}
+	var publicacoesIDs []interface{}
+	for _, publicacao := range publicacoes {
+		publicacoesIDs = append(publicacoesIDs, publicacao.ID)
+	}
+	linhas, erro := repositorio.db.Query(
+		"select "+colunasDeAnexo+" from anexos where publicacao_id in ("+marcadores(len(publicacoesIDs))+") order by posicao",
+		publicacoesIDs...)
+	if erro != nil {
+		return erro
+	}
+	defer linhas.Close()
+	anexos := map[uint64][]modelos.Anexo{}
+	for linhas.Next() {
+		anexo, erro := escanearAnexo(linhas)
+		if erro != nil {
+			return erro
+		}
+		anexos[*anexo.PublicacaoID] = append(anexos[*anexo.PublicacaoID], anexo)
+	}
+	for i := range publicacoes {
+		publicacoes[i].Anexos = anexos[publicacoes[i].ID]
+	}
+	return nil
+}
+
+// BuscarOrfaos traz os anexos criados antes de limite que não estão em nenhuma publicação nem num perfil,
+// seja porque nunca foram usados, porque a publicação foi apagada de vez ou porque o avatar foi trocado
+func (repositorio Anexos) BuscarOrfaos(limite time.Time) ([]modelos.Anexo, error) {
+	linhas, erro := repositorio.db.Query(
+		"select "+colunasDeAnexo+" from anexos where publicacao_id is null and criadoEm < ? and not "+usadoNoPerfil, limite)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var anexos []modelos.Anexo
+	for linhas.Next() {
+		anexo, erro := escanearAnexo(linhas)
+		if erro != nil {
+			return nil, erro
+		}
+		anexos = append(anexos, anexo)
+	}
+	return anexos, nil
+}
+
+// Deletar apaga o anexo do banco, os arquivos dele no armazenamento ficam por conta de quem chama
+func (repositorio Anexos) Deletar(anexoID uint64) error {
+	_, erro := repositorio.db.Exec("delete from anexos where id = ?", anexoID)
+	return erro
+}
+
+// escanearAnexo lê uma linha com as colunas de colunasDeAnexo e monta as urls do anexo
+func escanearAnexo(linhas *sql.Rows) (modelos.Anexo, error) {
+	var anexo modelos.Anexo
+	if erro := linhas.Scan(&anexo.ID, &anexo.PublicacaoID, &anexo.DonoID, &anexo.Tipo, &anexo.Largura, &anexo.Altura,
+		&anexo.Tamanho, &anexo.TextoAlternativo, &anexo.Chave, &anexo.ChaveMiniatura, &anexo.CriadoEm); erro != nil {
+		return modelos.Anexo{}, erro
+	}
+	anexo.URL = urlDoAnexo(anexo.ID)
+	anexo.MiniaturaURL = urlDaMiniatura(anexo.ID)
+	return anexo, nil
+}
+
+// urlDoAnexo é a rota que entrega a imagem do anexo
+func urlDoAnexo(anexoID uint64) string {
+	return fmt.Sprintf("/anexos/%d/arquivo", anexoID)
+}
+
+// urlDaMiniatura é a rota que entrega a miniatura do anexo
+func urlDaMiniatura(anexoID uint64) string {
+	return fmt.Sprintf("/anexos/%d/miniatura", anexoID)
+}
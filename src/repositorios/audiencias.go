@@ -0,0 +1,150 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"database/sql"
+)
+
+// Audiencias representa o repositório das audiências, as listas de usuários para quem se pode publicar
+type Audiencias struct {
+	db executor
+}
+
+// NovoRepositorioDeAudiencias cria um repositorio de audiências
+func NovoRepositorioDeAudiencias(db *sql.DB) *Audiencias {
+	return &Audiencias{db}
+}
+
+// Criar insere uma audiência de donoID com os membros dela. Ids de usuários que não existem são ignorados.
+// São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Audiencias) Criar(donoID uint64, audiencia modelos.Audiencia) (uint64, error) {
+	resultado, erro := repositorio.db.Exec("insert into audiencias (dono_id, nome) values (?, ?)", donoID, audiencia.Nome)
+	if erro != nil {
+		return 0, erro
+	}
+	audienciaID, erro := resultado.LastInsertId()
+	if erro != nil {
+		return 0, erro
+	}
+	if erro = repositorio.salvarMembros(uint64(audienciaID), audiencia.MembrosIDs); erro != nil {
+		return 0, erro
+	}
+	return uint64(audienciaID), nil
+}
+
+// Buscar traz as audiências de donoID, sem os membros
+func (repositorio Audiencias) Buscar(donoID uint64) ([]modelos.Audiencia, error) {
+	linhas, erro := repositorio.db.Query("select id, nome, criadoEm from audiencias where dono_id = ? order by nome, id", donoID)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var audiencias []modelos.Audiencia
+	for linhas.Next() {
+		var audiencia modelos.Audiencia
+		if erro = linhas.Scan(&audiencia.ID, &audiencia.Nome, &audiencia.CriadoEm); erro != nil {
+			return nil, erro
+		}
+		audiencias = append(audiencias, audiencia)
+	}
+	return audiencias, nil
+}
+
+// BuscarPorID traz uma audiência de donoID com os membros dela. Audiência de outro dono volta vazia
+func (repositorio Audiencias) BuscarPorID(audienciaID, donoID uint64) (modelos.Audiencia, error) {
+	var audiencia modelos.Audiencia
+	erro := repositorio.db.QueryRow(
+		"select id, nome, criadoEm from audiencias where id = ? and dono_id = ?", audienciaID, donoID).Scan(&audiencia.ID, &audiencia.Nome, &audiencia.CriadoEm)
+	if erro == sql.ErrNoRows {
+		return modelos.Audiencia{}, nil
+	}
+	if erro != nil {
+		return modelos.Audiencia{}, erro
+	}
+	linhas, erro := repositorio.db.Query(
+		"select u.id, u.nome, u.nick from membros_audiencias m inner join usuarios u on u.id = m.usuario_id where m.audiencia_id = ? order by u.nick", audienciaID)
+	if erro != nil {
+		return modelos.Audiencia{}, erro
+	}
+	defer linhas.Close()
+	for linhas.Next() {
+		var membro modelos.Usuario
+		if erro = linhas.Scan(&membro.ID, &membro.Nome, &membro.Nick); erro != nil {
+			return modelos.Audiencia{}, erro
+		}
+		audiencia.Membros = append(audiencia.Membros, membro)
+		audiencia.MembrosIDs = append(audiencia.MembrosIDs, membro.ID)
+	}
+	return audiencia, nil
+}
+
+// Atualizar troca o nome e a lista inteira de membros de uma audiência. Quem sai deixa de ver as publicações dela.
+// São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Audiencias) Atualizar(audienciaID uint64, audiencia modelos.Audiencia) error {
+	if _, erro := repositorio.db.Exec("update audiencias set nome = ? where id = ?", audiencia.Nome, audienciaID); erro != nil {
+		return erro
+	}
+	if _, erro := repositorio.db.Exec("delete from membros_audiencias where audiencia_id = ?", audienciaID); erro != nil {
+		return erro
+	}
+	if erro := repositorio.salvarMembros(audienciaID, audiencia.MembrosIDs); erro != nil {
+		return erro
+	}
+	invalidarFeeds(repositorio.db)
+	return nil
+}
+
+// Deletar apaga uma audiência. As publicações feitas para ela ficam visíveis só para o autor.
+// São dois comandos, então deve ser chamado dentro de uma transação
+func (repositorio Audiencias) Deletar(audienciaID uint64) error {
+	publicacoesIDs, erro := repositorio.publicacoes(audienciaID)
+	if erro != nil {
+		return erro
+	}
+	//a chave estrangeira das publicações vira null, e audiencia sem audiência não tem ninguém além do autor
+	if _, erro = repositorio.db.Exec("delete from audiencias where id = ?", audienciaID); erro != nil {
+		return erro
+	}
+	chaves := make([]string, len(publicacoesIDs))
+	for i, publicacaoID := range publicacoesIDs {
+		chaves[i] = chavePublicacao(publicacaoID)
+	}
+	if len(chaves) > 0 {
+		invalidarCache(repositorio.db, chaves...)
+	}
+	invalidarFeeds(repositorio.db)
+	return nil
+}
+
+// salvarMembros insere os membros de uma audiência, ignorando ids de usuários que não existem
+func (repositorio Audiencias) salvarMembros(audienciaID uint64, membrosIDs []uint64) error {
+	if len(membrosIDs) == 0 {
+		return nil
+	}
+	argumentosDosMembros := make([]interface{}, len(membrosIDs))
+	for i, membroID := range membrosIDs {
+		argumentosDosMembros[i] = membroID
+	}
+	_, erro := repositorio.db.Exec(
+		"insert ignore into membros_audiencias (audiencia_id, usuario_id) select ?, id from usuarios where id in ("+marcadores(len(membrosIDs))+")",
+		argumentos(audienciaID, argumentosDosMembros)...)
+	return erro
+}
+
+// publicacoes traz os ids das publicações feitas para uma audiência
+func (repositorio Audiencias) publicacoes(audienciaID uint64) ([]uint64, error) {
+	linhas, erro := repositorio.db.Query("select id from publicacoes where audiencia_id = ?", audienciaID)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var ids []uint64
+	for linhas.Next() {
+		var id uint64
+		if erro = linhas.Scan(&id); erro != nil {
+			return nil, erro
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,68 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"fmt"
+)
+
+// semBloqueio é o filtro que esconde as linhas em que coluna é um usuário que bloqueou ou foi bloqueado por quem está vendo.
+// Usa dois argumentos, ambos o id de quem está vendo
+func semBloqueio(coluna string) string {
+	return fmt.Sprintf(
+		"not exists (select 1 from bloqueios b where (b.bloqueador_id = ? and b.bloqueado_id = %[1]s) or (b.bloqueador_id = %[1]s and b.bloqueado_id = ?))", coluna)
+}
+
+// Bloquear faz bloqueadorID bloquear bloqueadoID, desfazendo quem segue quem e os pedidos para seguir entre os dois.
+// São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Usuarios) Bloquear(bloqueadorID, bloqueadoID uint64) error {
+	if _, erro := repositorio.db.Exec(
+		"insert ignore into bloqueios (bloqueador_id, bloqueado_id) values (?, ?)", bloqueadorID, bloqueadoID); erro != nil {
+		return erro
+	}
+	if erro := repositorio.PararDeSeguir(bloqueadorID, bloqueadoID); erro != nil {
+		return erro
+	}
+	if erro := repositorio.PararDeSeguir(bloqueadoID, bloqueadorID); erro != nil {
+		return erro
+	}
+	invalidarFeeds(repositorio.db)
+	return nil
+}
+
+// Desbloquear desfaz o bloqueio de bloqueadorID em bloqueadoID. Quem seguia quem não volta
+func (repositorio Usuarios) Desbloquear(bloqueadorID, bloqueadoID uint64) error {
+	if _, erro := repositorio.db.Exec(
+		"delete from bloqueios where bloqueador_id = ? and bloqueado_id = ?", bloqueadorID, bloqueadoID); erro != nil {
+		return erro
+	}
+	invalidarFeeds(repositorio.db)
+	return nil
+}
+
+// Bloqueado diz se algum dos dois usuários bloqueou o outro
+func (repositorio Usuarios) Bloqueado(usuarioID, outroID uint64) (bool, error) {
+	var bloqueado bool
+	erro := repositorio.db.QueryRow(
+		"select count(*) > 0 from bloqueios where (bloqueador_id = ? and bloqueado_id = ?) or (bloqueador_id = ? and bloqueado_id = ?)",
+		usuarioID, outroID, outroID, usuarioID).Scan(&bloqueado)
+	return bloqueado, erro
+}
+
+// BuscarBloqueados traz os usuários que usuarioID bloqueou, do bloqueio mais recente para o mais antigo
+func (repositorio Usuarios) BuscarBloqueados(usuarioID uint64) ([]modelos.Usuario, error) {
+	linhas, erro := repositorio.db.Query(
+		"select u.id, u.nome, u.nick from bloqueios b inner join usuarios u on u.id = b.bloqueado_id where b.bloqueador_id = ? order by b.criadoEm desc", usuarioID)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var usuarios []modelos.Usuario
+	for linhas.Next() {
+		var usuario modelos.Usuario
+		if erro = linhas.Scan(&usuario.ID, &usuario.Nome, &usuario.Nick); erro != nil {
+			return nil, erro
+		}
+		usuarios = append(usuarios, usuario)
+	}
+	return usuarios, nil
+}
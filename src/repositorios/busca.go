@@ -0,0 +1,175 @@
+package repositorios
+
+import (
+	"api/src/busca"
+	"api/src/modelos"
+	"database/sql"
+	"log"
+	"strings"
+)
+
+// indiceMySQL faz a busca de texto com o índice FULLTEXT da tabela publicacoes, o próprio banco mantém o índice
+type indiceMySQL struct {
+	db executor
+}
+
+// NovoIndiceDeBusca retorna o índice de busca configurado: o em memória se houver um, senão o do banco
+func NovoIndiceDeBusca(db *sql.DB) busca.Indice {
+	if indice := busca.IndiceAtual(); indice != nil {
+		return indice
+	}
+	return indiceMySQL{db}
+}
+
+// CarregarIndiceEmMemoria lê todas as publicações do banco para um índice em memória e passa a usá-lo nas buscas
+func CarregarIndiceEmMemoria(db *sql.DB) error {
+	linhas, erro := db.Query("select id, autor_id, titulo, conteudo, criadoEm from publicacoes where deletadoEm is null")
+	if erro != nil {
+		return erro
+	}
+	defer linhas.Close()
+	indice := busca.NovaMemoria()
+	for linhas.Next() {
+		var documento busca.Documento
+		if erro = linhas.Scan(
+			&documento.PublicacaoID,
+			&documento.AutorID,
+			&documento.Titulo,
+			&documento.Conteudo,
+			&documento.CriadoEm,
+		); erro != nil {
+			return erro
+		}
+		indice.Indexar(documento)
+	}
+	if erro = linhas.Err(); erro != nil {
+		return erro
+	}
+	busca.UsarIndice(indice)
+	return nil
+}
+
+// Indexar não faz nada, o FULLTEXT é atualizado pelo banco
+func (indiceMySQL) Indexar(busca.Documento) error { return nil }
+
+// Remover não faz nada, o FULLTEXT é atualizado pelo banco
+func (indiceMySQL) Remover(uint64) error { return nil }
+
+// Buscar usa match ... against no modo de linguagem natural e multiplica a relevância pelo fator de recência
+func (indice indiceMySQL) Buscar(consulta busca.Consulta) ([]busca.Resultado, error) {
+	//a mesma conta de busca.FatorDeRecencia: 0.5 ^ (idade / meia vida)
+	query := "select id, match(titulo, conteudo) against (? in natural language mode) * pow(0.5, timestampdiff(second, criadoEm, now()) / ?) as pontuacao " +
+		"from publicacoes where match(titulo, conteudo) against (? in natural language mode) and deletadoEm is null"
+	argumentos := []interface{}{consulta.Texto, busca.MeiaVida.Seconds(), consulta.Texto}
+	var filtros []string
+	if consulta.AutorID != 0 {
+		filtros = append(filtros, "autor_id = ?")
+		argumentos = append(argumentos, consulta.AutorID)
+	}
+	if !consulta.De.IsZero() {
+		filtros = append(filtros, "criadoEm >= ?")
+		argumentos = append(argumentos, consulta.De)
+	}
+	if !consulta.Ate.IsZero() {
+		filtros = append(filtros, "criadoEm < ?")
+		argumentos = append(argumentos, consulta.Ate)
+	}
+	for _, filtro := range filtros {
+		query += " and " + filtro
+	}
+	query += " order by pontuacao desc, id desc limit ? offset ?"
+	argumentos = append(argumentos, consulta.Limite, (consulta.Pagina-1)*consulta.Limite)
+
+	linhas, erro := indice.db.Query(query, argumentos...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var resultados []busca.Resultado
+	for linhas.Next() {
+		var resultado busca.Resultado
+		if erro = linhas.Scan(&resultado.PublicacaoID, &resultado.Pontuacao); erro != nil {
+			return nil, erro
+		}
+		resultados = append(resultados, resultado)
+	}
+	return resultados, nil
+}
+
+const (
+	//loteDaPesquisa é quantos resultados são pedidos ao índice de cada vez ao montar uma página da pesquisa
+	loteDaPesquisa = 100
+	//resultadosMaximosDaPesquisa limita quantos resultados do índice uma pesquisa percorre atrás de publicações visíveis
+	resultadosMaximosDaPesquisa = 5000
+)
+
+// Pesquisar traz a página da consulta só com as publicações que usuarioLogadoID pode ver, junto da pontuação de cada uma.
+// O índice não sabe quem pode ver o quê, então os resultados são lidos dele em lotes e filtrados até encher a página,
+// assim uma página não volta curta enquanto as seguintes ainda têm resultados
+func (repositorio Publicacoes) Pesquisar(indice busca.Indice, consulta busca.Consulta, usuarioLogadoID uint64) ([]modelos.Publicacao, map[uint64]float64, error) {
+	pular := (consulta.Pagina - 1) * consulta.Limite
+	var publicacoesIDs []uint64
+	pontuacoes := map[uint64]float64{}
+	lote := consulta
+	lote.Limite = loteDaPesquisa
+	for lote.Pagina = 1; (lote.Pagina-1)*loteDaPesquisa < resultadosMaximosDaPesquisa && len(publicacoesIDs) < consulta.Limite; lote.Pagina++ {
+		resultados, erro := indice.Buscar(lote)
+		if erro != nil {
+			return nil, nil, erro
+		}
+		ids := make([]interface{}, len(resultados))
+		for i, resultado := range resultados {
+			ids[i] = resultado.PublicacaoID
+		}
+		visiveis, erro := repositorio.filtrarVisiveis(ids, usuarioLogadoID)
+		if erro != nil {
+			return nil, nil, erro
+		}
+		for _, resultado := range resultados {
+			if !visiveis[resultado.PublicacaoID] || len(publicacoesIDs) == consulta.Limite {
+				continue
+			}
+			//as publicações visíveis das páginas anteriores são puladas
+			if pular > 0 {
+				pular--
+				continue
+			}
+			publicacoesIDs = append(publicacoesIDs, resultado.PublicacaoID)
+			pontuacoes[resultado.PublicacaoID] = resultado.Pontuacao
+		}
+		//o índice acabou
+		if len(resultados) < loteDaPesquisa {
+			break
+		}
+	}
+	publicacoes, erro := repositorio.BuscarPorIDs(publicacoesIDs, usuarioLogadoID)
+	return publicacoes, pontuacoes, erro
+}
+
+// indexarPublicacao mantém o índice em memória, quando ele é usado, igual ao banco depois da escrita feita por db ser confirmada
+func indexarPublicacao(db executor, documento busca.Documento) {
+	depoisDoCommit(db, func() {
+		if erro := busca.Indexar(documento); erro != nil {
+			log.Printf("erro ao indexar publicação %d: %v", documento.PublicacaoID, erro)
+		}
+	})
+}
+
+// removerPublicacaoDoIndice tira a publicação do índice em memória, quando ele é usado, depois da escrita feita por db ser confirmada
+func removerPublicacaoDoIndice(db executor, publicacaoID uint64) {
+	depoisDoCommit(db, func() {
+		if erro := busca.Remover(publicacaoID); erro != nil {
+			log.Printf("erro ao remover publicação %d do índice: %v", publicacaoID, erro)
+		}
+	})
+}
+
+// marcadores retorna "?,?,?" com quantidade interrogações, para montar cláusulas in
+func marcadores(quantidade int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", quantidade), ",")
+}
+
+// escaparLike escapa os curingas do like (% e _) para o texto ser comparado literalmente
+func escaparLike(texto string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(texto)
+}
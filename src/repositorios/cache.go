@@ -0,0 +1,88 @@
+package repositorios
+
+import (
+	"api/src/banco"
+	"api/src/cache"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// chaveGeracaoDosFeeds guarda um contador que muda sempre que alguma publicação muda,
+// os feeds em cache levam esse número na chave e ficam inacessíveis quando ele é incrementado
+const chaveGeracaoDosFeeds = "feeds:geracao"
+
+// chaveUsuario é a chave de cache de um usuário buscado por id
+func chaveUsuario(usuarioID uint64) string {
+	return fmt.Sprintf("usuario:%d", usuarioID)
+}
+
+// chavePublicacao é a chave de cache de uma publicação buscada por id
+func chavePublicacao(publicacaoID uint64) string {
+	return fmt.Sprintf("publicacao:%d", publicacaoID)
+}
+
+// chaveFeed é a chave de cache do feed de um usuário na geração atual dos feeds
+func chaveFeed(usuarioID uint64) string {
+	geracao, erro := cache.Contador(chaveGeracaoDosFeeds)
+	if erro != nil {
+		log.Printf("erro ao ler geração dos feeds no cache: %v", erro)
+	}
+	return fmt.Sprintf("feed:%d:%d", geracao, usuarioID)
+}
+
+// buscarDoCache preenche destino com o valor em cache de chave e diz se encontrou.
+// Erros do cache só são registrados, na dúvida a busca vai pro banco. Dentro de uma transação o cache
+// não é usado, ela precisa ver o banco como ela mesma deixou
+func buscarDoCache(db executor, chave string, destino interface{}) bool {
+	if emTransacao(db) {
+		return false
+	}
+	valor, encontrado, erro := cache.Buscar(chave)
+	if erro != nil {
+		log.Printf("erro ao buscar %s no cache: %v", chave, erro)
+		return false
+	}
+	if !encontrado {
+		return false
+	}
+	return json.Unmarshal(valor, destino) == nil
+}
+
+// salvarNoCache guarda valor, lido por db, em chave. O que foi lido dentro de uma transação não vai pro cache,
+// já que ela ainda pode ser desfeita, nem o que veio de uma réplica: atrasada, ela pode devolver ao cache a linha
+// que uma escrita acabou de invalidar, e quem escreveu leria a versão velha mesmo lendo do primário
+func salvarNoCache(db executor, chave string, valor interface{}) {
+	if emTransacao(db) {
+		return
+	}
+	if conexao, ehConexao := db.(*sql.DB); ehConexao && banco.EhReplica(conexao) {
+		return
+	}
+	dados, erro := json.Marshal(valor)
+	if erro == nil {
+		erro = cache.Salvar(chave, dados)
+	}
+	if erro != nil {
+		log.Printf("erro ao salvar %s no cache: %v", chave, erro)
+	}
+}
+
+// invalidarCache apaga as chaves do cache depois da escrita feita por db ser confirmada
+func invalidarCache(db executor, chaves ...string) {
+	depoisDoCommit(db, func() {
+		if erro := cache.Remover(chaves...); erro != nil {
+			log.Printf("erro ao invalidar %v no cache: %v", chaves, erro)
+		}
+	})
+}
+
+// invalidarFeeds descarta de uma vez todos os feeds em cache depois da escrita feita por db ser confirmada
+func invalidarFeeds(db executor) {
+	depoisDoCommit(db, func() {
+		if _, erro := cache.Incrementar(chaveGeracaoDosFeeds); erro != nil {
+			log.Printf("erro ao invalidar feeds no cache: %v", erro)
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"database/sql"
+	"fmt"
+)
+
+// colunasDeComentario são as colunas lidas em toda busca de comentários, na ordem em que escanearComentario espera.
+// O autor de um comentário de conta apagada é nulo, e ele volta com autor 0 e sem nick
+const colunasDeComentario = "c.id, c.publicacao_id, coalesce(c.autor_id, 0), coalesce(u.nick, ''), c.pai_id, c.profundidade, c.conteudo, c.criadoEm, c.editadoEm, c.deletadoEm is not null"
+
+// Comentarios representa o repositório de comentários
+type Comentarios struct {
+	db executor
+}
+
+// NovoRepositorioDeComentarios cria um repositorio de comentários
+func NovoRepositorioDeComentarios(db *sql.DB) *Comentarios {
+	return &Comentarios{db}
+}
+
+// Criar insere um comentário, respondendo a pai se ele não for nil, e incrementa o contador da publicação.
+// O caminho do comentário (ids dos ancestrais e o dele) é o que permite buscar a árvore já em profundidade.
+// São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Comentarios) Criar(comentario modelos.Comentario, pai *modelos.Comentario) (uint64, error) {
+	caminhoDoPai := ""
+	if pai != nil {
+		if erro := repositorio.db.QueryRow("select caminho from comentarios where id = ?", pai.ID).Scan(&caminhoDoPai); erro != nil {
+			return 0, erro
+		}
+		comentario.PaiID = &pai.ID
+		comentario.Profundidade = pai.Profundidade + 1
+	}
+	statement, erro := repositorio.db.Prepare(
+		"insert into comentarios (publicacao_id, autor_id, pai_id, profundidade, conteudo, caminho) values (?,?,?,?,?,'')")
+	if erro != nil {
+		return 0, erro
+	}
+	defer statement.Close()
+	resultado, erro := statement.Exec(comentario.PublicacaoID, comentario.AutorID, comentario.PaiID, comentario.Profundidade, comentario.Conteudo)
+	if erro != nil {
+		return 0, erro
+	}
+	ultimoIDInserido, erro := resultado.LastInsertId()
+	if erro != nil {
+		return 0, erro
+	}
+	//o id com zeros à esquerda faz a ordem alfabética do caminho ser a ordem da árvore em profundidade
+	caminho := fmt.Sprintf("%s%010d/", caminhoDoPai, ultimoIDInserido)
+	if _, erro = repositorio.db.Exec("update comentarios set caminho = ? where id = ?", caminho, ultimoIDInserido); erro != nil {
+		return 0, erro
+	}
+	if _, erro = repositorio.db.Exec("update publicacoes set comentarios = comentarios + 1 where id = ?", comentario.PublicacaoID); erro != nil {
+		return 0, erro
+	}
+	invalidarCache(repositorio.db, chavePublicacao(comentario.PublicacaoID))
+	invalidarFeeds(repositorio.db)
+	return uint64(ultimoIDInserido), nil
+}
+
+// BuscarPorID traz um comentário pelo seu id, inclusive se ele estiver deletado
+func (repositorio Comentarios) BuscarPorID(comentarioID uint64) (modelos.Comentario, error) {
+	linhas, erro := repositorio.db.Query(
+		"select "+colunasDeComentario+" from comentarios c left join usuarios u on u.id = c.autor_id where c.id = ?", comentarioID)
+	if erro != nil {
+		return modelos.Comentario{}, erro
+	}
+	defer linhas.Close()
+	var comentario modelos.Comentario
+	if linhas.Next() {
+		if comentario, erro = escanearComentario(linhas); erro != nil {
+			return modelos.Comentario{}, erro
+		}
+	}
+	return comentario, nil
+}
+
+// BuscarArvore traz uma página dos comentários de uma publicação em profundidade: cada comentário seguido das
+// suas respostas. Se raizID não for 0 traz só as respostas (diretas e indiretas) do comentário raizID.
+// Os comentários de quem tem bloqueio com usuarioLogadoID ficam de fora
+func (repositorio Comentarios) BuscarArvore(publicacaoID, raizID, usuarioLogadoID uint64, pagina, limite int) ([]modelos.Comentario, error) {
+	prefixo := ""
+	if raizID != 0 {
+		if erro := repositorio.db.QueryRow("select caminho from comentarios where id = ?", raizID).Scan(&prefixo); erro != nil {
+			return nil, erro
+		}
+	}
+	linhas, erro := repositorio.db.Query(
+		"select "+colunasDeComentario+" from comentarios c left join usuarios u on u.id = c.autor_id "+
+			"where c.publicacao_id = ? and c.caminho like ? and c.id <> ? and "+semBloqueio("c.autor_id")+" order by c.caminho limit ? offset ?",
+		publicacaoID, prefixo+"%", raizID, usuarioLogadoID, usuarioLogadoID, limite, (pagina-1)*limite)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var comentarios []modelos.Comentario
+	for linhas.Next() {
+		comentario, erro := escanearComentario(linhas)
+		if erro != nil {
+			return nil, erro
+		}
+		comentarios = append(comentarios, comentario)
+	}
+	return comentarios, nil
+}
+
+// Atualizar altera o conteúdo de um comentário e marca ele como editado
+func (repositorio Comentarios) Atualizar(comentarioID uint64, comentario modelos.Comentario) error {
+	statement, erro := repositorio.db.Prepare(
+		"update comentarios set conteudo = ?, editadoEm = now() where id = ? and deletadoEm is null")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+	_, erro = statement.Exec(comentario.Conteudo, comentarioID)
+	return erro
+}
+
+// Deletar apaga o conteúdo do comentário e decrementa o contador da publicação. A linha continua
+// para as respostas não perderem o lugar na árvore. São dois comandos, então deve ser chamado dentro de uma transação
+func (repositorio Comentarios) Deletar(comentario modelos.Comentario) error {
+	statement, erro := repositorio.db.Prepare(
+		"update comentarios set conteudo = '', deletadoEm = now() where id = ? and deletadoEm is null")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+	resultado, erro := statement.Exec(comentario.ID)
+	if erro != nil {
+		return erro
+	}
+	if linhasAfetadas, erro := resultado.RowsAffected(); erro != nil || linhasAfetadas == 0 {
+		return erro
+	}
+	if _, erro = repositorio.db.Exec(
+		"update publicacoes set comentarios = CASE WHEN comentarios > 0 THEN comentarios - 1 ELSE comentarios END where id = ?", comentario.PublicacaoID); erro != nil {
+		return erro
+	}
+	invalidarCache(repositorio.db, chavePublicacao(comentario.PublicacaoID))
+	invalidarFeeds(repositorio.db)
+	return nil
+}
+
+// escanearComentario lê uma linha com as colunas de colunasDeComentario
+func escanearComentario(linhas *sql.Rows) (modelos.Comentario, error) {
+	var comentario modelos.Comentario
+	var paiID sql.NullInt64
+	erro := linhas.Scan(
+		&comentario.ID,
+		&comentario.PublicacaoID,
+		&comentario.AutorID,
+		&comentario.AutorNick,
+		&paiID,
+		&comentario.Profundidade,
+		&comentario.Conteudo,
+		&comentario.CriadoEm,
+		&comentario.EditadoEm,
+		&comentario.Deletado,
+	)
+	if paiID.Valid {
+		id := uint64(paiID.Int64)
+		comentario.PaiID = &id
+	}
+	return comentario, erro
+}
@@ -0,0 +1,270 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+)
+
+// colunasDosContadores são os contadores guardados em usuarios, com o apelido u para usuarios
+const colunasDosContadores = "u.seguidores, u.seguindo, u.publicacoes"
+
+// loteDeRecalculo é quantos usuários ou publicações são conferidos em cada lote de RecalcularContadores
+const loteDeRecalculo = 1000
+
+// ajustarContadoresDeSeguir soma delta aos seguidores de usuarioID e a quem seguidorID segue
+func (repositorio Usuarios) ajustarContadoresDeSeguir(usuarioID, seguidorID uint64, delta int) error {
+	if _, erro := repositorio.db.Exec(
+		"update usuarios set seguidores = greatest(seguidores + ?, 0) where id = ?", delta, usuarioID); erro != nil {
+		return erro
+	}
+	_, erro := repositorio.db.Exec("update usuarios set seguindo = greatest(seguindo + ?, 0) where id = ?", delta, seguidorID)
+	return erro
+}
+
+// ajustarContadorDePublicacoes soma delta às publicações de autorID
+func (repositorio Publicacoes) ajustarContadorDePublicacoes(autorID uint64, delta int) error {
+	if _, erro := repositorio.db.Exec(
+		"update usuarios set publicacoes = greatest(publicacoes + ?, 0) where id = ?", delta, autorID); erro != nil {
+		return erro
+	}
+	invalidarCache(repositorio.db, chaveUsuario(autorID))
+	return nil
+}
+
+// descontarRelacoes tira usuarioID dos contadores de quem ele segue e de quem o segue, para quando ele vai ser apagado
+// e as linhas de seguidores somem em cascata. São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Usuarios) descontarRelacoes(usuarioID uint64) error {
+	seguidos, erro := repositorio.contarPorUsuario("select usuario_id, 1 from seguidores where seguidor_id = ?", []interface{}{usuarioID})
+	if erro != nil {
+		return erro
+	}
+	seguidores, erro := repositorio.contarPorUsuario("select seguidor_id, 1 from seguidores where usuario_id = ?", []interface{}{usuarioID})
+	if erro != nil {
+		return erro
+	}
+	if _, erro = repositorio.db.Exec(
+		"update usuarios u inner join seguidores s on s.usuario_id = u.id set u.seguidores = greatest(u.seguidores - 1, 0) "+
+			"where s.seguidor_id = ?", usuarioID); erro != nil {
+		return erro
+	}
+	if _, erro = repositorio.db.Exec(
+		"update usuarios u inner join seguidores s on s.seguidor_id = u.id set u.seguindo = greatest(u.seguindo - 1, 0) "+
+			"where s.usuario_id = ?", usuarioID); erro != nil {
+		return erro
+	}
+	var chaves []string
+	for id := range seguidos {
+		chaves = append(chaves, chaveUsuario(id))
+	}
+	for id := range seguidores {
+		chaves = append(chaves, chaveUsuario(id))
+	}
+	invalidarCache(repositorio.db, chaves...)
+	return nil
+}
+
+// descontarInteracoes tira usuarioID dos contadores das publicações dos outros em que ele curtiu, republicou, comentou
+// ou que ele citou, para quando ele vai ser apagado: as curtidas, republicações e publicações dele somem junto e os
+// comentários viram apagados. As publicações mudadas saem do cache.
+// São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Usuarios) descontarInteracoes(usuarioID uint64) error {
+	afetadas, erro := repositorio.contarPorUsuario(
+		"select publicacao_id, 1 from curtidas where usuario_id = ? "+
+			"union select publicacao_id, 1 from republicacoes where usuario_id = ? "+
+			"union select publicacao_id, 1 from comentarios where autor_id = ? and deletadoEm is null "+
+			"union select citada_id, 1 from publicacoes where autor_id = ? and deletadoEm is null and citada_id is not null",
+		[]interface{}{usuarioID, usuarioID, usuarioID, usuarioID})
+	if erro != nil {
+		return erro
+	}
+	if len(afetadas) == 0 {
+		return nil
+	}
+	for _, query := range []string{
+		"update publicacoes p inner join curtidas c on c.publicacao_id = p.id set p.curtidas = greatest(p.curtidas - 1, 0) where c.usuario_id = ?",
+		"update publicacoes p inner join republicacoes r on r.publicacao_id = p.id set p.republicacoes = greatest(p.republicacoes - 1, 0) where r.usuario_id = ?",
+		"update publicacoes p inner join (select publicacao_id, count(*) quantidade from comentarios where autor_id = ? and deletadoEm is null group by publicacao_id) c " +
+			"on c.publicacao_id = p.id set p.comentarios = greatest(p.comentarios - c.quantidade, 0)",
+		"update publicacoes p inner join (select citada_id, count(*) quantidade from publicacoes where autor_id = ? and deletadoEm is null and citada_id is not null group by citada_id) q " +
+			"on q.citada_id = p.id set p.citacoes = greatest(p.citacoes - q.quantidade, 0)",
+	} {
+		if _, erro = repositorio.db.Exec(query, usuarioID); erro != nil {
+			return erro
+		}
+	}
+	chaves := make([]string, 0, len(afetadas))
+	for id := range afetadas {
+		chaves = append(chaves, chavePublicacao(id))
+	}
+	invalidarCache(repositorio.db, chaves...)
+	return nil
+}
+
+// contagens reais de cada contador de usuarios, com o apelido u para usuarios
+const (
+	seguidoresReais  = "(select count(*) from seguidores s where s.usuario_id = u.id)"
+	seguindoReais    = "(select count(*) from seguidores s where s.seguidor_id = u.id)"
+	publicacoesReais = "(select count(*) from publicacoes p where p.autor_id = u.id and p.deletadoEm is null)"
+)
+
+// RecalcularContadores conta de novo os seguidores, quem segue e as publicações de todos os usuários e corrige os
+// contadores que estiverem diferentes, como os de quem seguia um usuário apagado, tirando os corrigidos do cache.
+// Retorna quantos foram corrigidos
+func (repositorio Usuarios) RecalcularContadores() (int64, error) {
+	var maiorID uint64
+	if erro := repositorio.db.QueryRow("select coalesce(max(id), 0) from usuarios").Scan(&maiorID); erro != nil {
+		return 0, erro
+	}
+	var corrigidos int64
+	//em lotes para não travar a tabela toda de uma vez
+	for inicio := uint64(0); inicio < maiorID; inicio += loteDeRecalculo {
+		//primeiro descobrindo quem está errado, para saber quem tirar do cache
+		errados, erro := repositorio.contarPorUsuario(
+			"select u.id, 1 from usuarios u where u.id > ? and u.id <= ? and "+
+				"(u.seguidores <> "+seguidoresReais+" or u.seguindo <> "+seguindoReais+" or u.publicacoes <> "+publicacoesReais+")",
+			[]interface{}{inicio, inicio + loteDeRecalculo})
+		if erro != nil {
+			return corrigidos, erro
+		}
+		if len(errados) == 0 {
+			continue
+		}
+		var ids []interface{}
+		var chaves []string
+		for id := range errados {
+			ids = append(ids, id)
+			chaves = append(chaves, chaveUsuario(id))
+		}
+		if _, erro = repositorio.db.Exec(
+			"update usuarios u set seguidores = "+seguidoresReais+", seguindo = "+seguindoReais+", publicacoes = "+publicacoesReais+" "+
+				"where u.id in ("+marcadores(len(ids))+")", ids...); erro != nil {
+			return corrigidos, erro
+		}
+		invalidarCache(repositorio.db, chaves...)
+		corrigidos += int64(len(ids))
+	}
+	return corrigidos, nil
+}
+
+// contagens reais dos contadores de publicacoes, com o apelido p para publicacoes. As citações ficam fora porque
+// contam na própria tabela publicacoes, que o mysql não deixa ler numa subquery do update que a altera
+const (
+	curtidasReais      = "(select count(*) from curtidas c where c.publicacao_id = p.id)"
+	comentariosReais   = "(select count(*) from comentarios c where c.publicacao_id = p.id and c.deletadoEm is null)"
+	republicacoesReais = "(select count(*) from republicacoes r where r.publicacao_id = p.id)"
+	citacoesReais      = "(select count(*) from publicacoes q where q.citada_id = p.id and q.deletadoEm is null)"
+)
+
+// RecalcularContadores conta de novo as curtidas, os comentários, as republicações e as citações de todas as
+// publicações e corrige os contadores que estiverem diferentes, como os das publicações de quem interagiu com
+// um usuário apagado, tirando as corrigidas do cache. Retorna quantas foram corrigidas
+func (repositorio Publicacoes) RecalcularContadores() (int64, error) {
+	var maiorID uint64
+	if erro := repositorio.db.QueryRow("select coalesce(max(id), 0) from publicacoes").Scan(&maiorID); erro != nil {
+		return 0, erro
+	}
+	var corrigidos int64
+	//em lotes para não travar a tabela toda de uma vez
+	for inicio := uint64(0); inicio < maiorID; inicio += loteDeRecalculo {
+		//primeiro descobrindo quais estão erradas, para saber quais tirar do cache
+		ids, erro := repositorio.buscarIDs(
+			"select p.id from publicacoes p where p.id > ? and p.id <= ? and "+
+				"(p.curtidas <> "+curtidasReais+" or p.comentarios <> "+comentariosReais+" or p.republicacoes <> "+republicacoesReais+
+				" or p.citacoes <> "+citacoesReais+")",
+			inicio, inicio+loteDeRecalculo)
+		if erro != nil {
+			return corrigidos, erro
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		var chaves []string
+		for _, id := range ids {
+			chaves = append(chaves, chavePublicacao(id.(uint64)))
+		}
+		//o group by faz o mysql materializar a contagem das citações antes do update
+		if _, erro = repositorio.db.Exec(
+			"update publicacoes p left join (select citada_id, count(*) quantidade from publicacoes where deletadoEm is null and citada_id in ("+
+				marcadores(len(ids))+") group by citada_id) q on q.citada_id = p.id "+
+				"set p.curtidas = "+curtidasReais+", p.comentarios = "+comentariosReais+", p.republicacoes = "+republicacoesReais+
+				", p.citacoes = coalesce(q.quantidade, 0) where p.id in ("+marcadores(len(ids))+")",
+			argumentos(ids, ids)...); erro != nil {
+			return corrigidos, erro
+		}
+		invalidarCache(repositorio.db, chaves...)
+		corrigidos += int64(len(ids))
+	}
+	return corrigidos, nil
+}
+
+// buscarIDs executa uma query que traz só ids de publicações, já prontos para virar argumentos de outra query
+func (repositorio Publicacoes) buscarIDs(query string, args ...interface{}) ([]interface{}, error) {
+	linhas, erro := repositorio.db.Query(query, args...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var ids []interface{}
+	for linhas.Next() {
+		var id uint64
+		if erro = linhas.Scan(&id); erro != nil {
+			return nil, erro
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// PreencherRelacoes completa os usuários com a relação deles com usuarioLogadoID: se ele os segue, se eles o seguem
+// e quantos de quem ele segue também os seguem. Fica fora do cache, que guarda os usuários iguais para todo mundo
+func (repositorio Usuarios) PreencherRelacoes(usuarios []modelos.Usuario, usuarioLogadoID uint64) error {
+	if len(usuarios) == 0 || usuarioLogadoID == 0 {
+		return nil
+	}
+	ids := make([]interface{}, len(usuarios))
+	for i, usuario := range usuarios {
+		ids[i] = usuario.ID
+	}
+	seguidos, erro := repositorio.contarPorUsuario(
+		"select usuario_id, 1 from seguidores where seguidor_id = ? and usuario_id in ("+marcadores(len(ids))+")",
+		argumentos(usuarioLogadoID, ids))
+	if erro != nil {
+		return erro
+	}
+	seguidores, erro := repositorio.contarPorUsuario(
+		"select seguidor_id, 1 from seguidores where usuario_id = ? and seguidor_id in ("+marcadores(len(ids))+")",
+		argumentos(usuarioLogadoID, ids))
+	if erro != nil {
+		return erro
+	}
+	emComum, erro := repositorio.contarPorUsuario(
+		"select s.usuario_id, count(*) from seguidores s inner join seguidores v on v.usuario_id = s.seguidor_id "+
+			"where v.seguidor_id = ? and s.usuario_id in ("+marcadores(len(ids))+") group by s.usuario_id",
+		argumentos(usuarioLogadoID, ids))
+	if erro != nil {
+		return erro
+	}
+	for i := range usuarios {
+		usuarios[i].SeguidoPorMim = seguidos[usuarios[i].ID] > 0
+		usuarios[i].MeSegue = seguidores[usuarios[i].ID] > 0
+		usuarios[i].SeguidoresEmComum = emComum[usuarios[i].ID]
+	}
+	return nil
+}
+
+// contarPorUsuario executa uma query que traz pares (usuário, quantidade) e os devolve num mapa
+func (repositorio Usuarios) contarPorUsuario(query string, args []interface{}) (map[uint64]uint64, error) {
+	linhas, erro := repositorio.db.Query(query, args...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	contagens := map[uint64]uint64{}
+	for linhas.Next() {
+		var usuarioID, quantidade uint64
+		if erro = linhas.Scan(&usuarioID, &quantidade); erro != nil {
+			return nil, erro
+		}
+		contagens[usuarioID] = quantidade
+	}
+	return contagens, nil
+}
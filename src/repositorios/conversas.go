@@ -0,0 +1,270 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"database/sql"
+	"fmt"
+)
+
+// colunasDeMensagem são as colunas lidas em toda busca de mensagens, na ordem em que escanearMensagem espera
+const colunasDeMensagem = "m.id, m.conversa_id, m.remetente_id, u.nick, m.conteudo, m.criadoEm, m.deletadoEm is not null"
+
+// Conversas representa o repositório de conversas e mensagens diretas
+type Conversas struct {
+	db executor
+}
+
+// NovoRepositorioDeConversas cria um repositorio de conversas
+func NovoRepositorioDeConversas(db *sql.DB) *Conversas {
+	return &Conversas{db}
+}
+
+// chaveDeConversaDireta identifica a conversa entre dois usuários independente de quem criou
+func chaveDeConversaDireta(usuarioID, outroID uint64) string {
+	if usuarioID > outroID {
+		usuarioID, outroID = outroID, usuarioID
+	}
+	return fmt.Sprintf("%d-%d", usuarioID, outroID)
+}
+
+// BuscarConversaDireta traz o id da conversa a dois entre os usuários, ou 0 se ainda não existir
+func (repositorio Conversas) BuscarConversaDireta(usuarioID, outroID uint64) (uint64, error) {
+	var conversaID uint64
+	erro := repositorio.db.QueryRow(
+		"select id from conversas where chave = ?", chaveDeConversaDireta(usuarioID, outroID)).Scan(&conversaID)
+	if erro == sql.ErrNoRows {
+		return 0, nil
+	}
+	return conversaID, erro
+}
+
+// Criar insere a conversa com o criador e os participantes dela.
+// São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Conversas) Criar(conversa modelos.Conversa, criadorID uint64) (uint64, error) {
+	var chave interface{}
+	if !conversa.Grupo {
+		chave = chaveDeConversaDireta(criadorID, conversa.ParticipantesIDs[0])
+	}
+	resultado, erro := repositorio.db.Exec(
+		"insert into conversas (nome, grupo, chave) values (?, ?, ?)", conversa.Nome, conversa.Grupo, chave)
+	if erro != nil {
+		return 0, erro
+	}
+	conversaID, erro := resultado.LastInsertId()
+	if erro != nil {
+		return 0, erro
+	}
+	statement, erro := repositorio.db.Prepare("insert into participantes_conversas (conversa_id, usuario_id) values (?, ?)")
+	if erro != nil {
+		return 0, erro
+	}
+	defer statement.Close()
+	for _, participanteID := range append([]uint64{criadorID}, conversa.ParticipantesIDs...) {
+		if _, erro = statement.Exec(conversaID, participanteID); erro != nil {
+			return 0, erro
+		}
+	}
+	return uint64(conversaID), nil
+}
+
+// BuscarPorID traz uma conversa como vista por usuarioID, com participantes, última mensagem e não lidas.
+// Se usuarioID não participa dela a conversa volta vazia
+func (repositorio Conversas) BuscarPorID(conversaID, usuarioID uint64) (modelos.Conversa, error) {
+	conversas, erro := repositorio.buscar(usuarioID, "and c.id = ?", conversaID)
+	if erro != nil || len(conversas) == 0 {
+		return modelos.Conversa{}, erro
+	}
+	return conversas[0], nil
+}
+
+// Buscar traz uma página das conversas de usuarioID, da que teve mensagem mais recente para a mais antiga
+func (repositorio Conversas) Buscar(usuarioID uint64, pagina, limite int) ([]modelos.Conversa, error) {
+	return repositorio.buscar(usuarioID, "order by c.atualizadoEm desc, c.id desc limit ? offset ?", limite, (pagina-1)*limite)
+}
+
+// buscar traz as conversas de usuarioID completas. O complemento vai no final da query, depois do filtro pelo usuário
+func (repositorio Conversas) buscar(usuarioID uint64, complemento string, argumentos ...interface{}) ([]modelos.Conversa, error) {
+	linhas, erro := repositorio.db.Query(
+		"select c.id, c.nome, c.grupo, c.criadoEm, c.atualizadoEm, "+
+			"(select count(*) from mensagens m where m.conversa_id = c.id and m.id > pc.lidoAte and m.remetente_id <> pc.usuario_id and m.deletadoEm is null) "+
+			"from participantes_conversas pc inner join conversas c on c.id = pc.conversa_id "+
+			"where pc.usuario_id = ? "+complemento,
+		append([]interface{}{usuarioID}, argumentos...)...)
+	if erro != nil {
+		return nil, erro
+	}
+	var conversas []modelos.Conversa
+	for linhas.Next() {
+		var conversa modelos.Conversa
+		var nome sql.NullString
+		if erro = linhas.Scan(&conversa.ID, &nome, &conversa.Grupo, &conversa.CriadoEm, &conversa.AtualizadoEm, &conversa.NaoLidas); erro != nil {
+			linhas.Close()
+			return nil, erro
+		}
+		conversa.Nome = nome.String
+		conversas = append(conversas, conversa)
+	}
+	linhas.Close()
+	if len(conversas) == 0 {
+		return nil, nil
+	}
+	return conversas, repositorio.completar(conversas)
+}
+
+// completar coloca os participantes e a última mensagem em cada conversa, com uma query para cada um dos dois
+func (repositorio Conversas) completar(conversas []modelos.Conversa) error {
+	posicoes := map[uint64]int{}
+	argumentos := make([]interface{}, len(conversas))
+	for i, conversa := range conversas {
+		posicoes[conversa.ID] = i
+		argumentos[i] = conversa.ID
+	}
+	linhas, erro := repositorio.db.Query(
+		"select pc.conversa_id, u.id, u.nome, u.nick from participantes_conversas pc inner join usuarios u on u.id = pc.usuario_id "+
+			"where pc.conversa_id in ("+marcadores(len(conversas))+") order by pc.entrouEm, u.id", argumentos...)
+	if erro != nil {
+		return erro
+	}
+	for linhas.Next() {
+		var conversaID uint64
+		var participante modelos.Usuario
+		if erro = linhas.Scan(&conversaID, &participante.ID, &participante.Nome, &participante.Nick); erro != nil {
+			linhas.Close()
+			return erro
+		}
+		conversa := &conversas[posicoes[conversaID]]
+		conversa.Participantes = append(conversa.Participantes, participante)
+	}
+	linhas.Close()
+	linhas, erro = repositorio.db.Query(
+		"select "+colunasDeMensagem+" from mensagens m inner join usuarios u on u.id = m.remetente_id "+
+			"where m.id in (select max(id) from mensagens where conversa_id in ("+marcadores(len(conversas))+") group by conversa_id)", argumentos...)
+	if erro != nil {
+		return erro
+	}
+	defer linhas.Close()
+	for linhas.Next() {
+		mensagem, erro := escanearMensagem(linhas)
+		if erro != nil {
+			return erro
+		}
+		conversas[posicoes[mensagem.ConversaID]].UltimaMensagem = &mensagem
+	}
+	return nil
+}
+
+// ContarNaoLidas traz quantas mensagens de outros usuários usuarioID ainda não leu, somando todas as conversas
+func (repositorio Conversas) ContarNaoLidas(usuarioID uint64) (uint64, error) {
+	var naoLidas uint64
+	erro := repositorio.db.QueryRow(
+		"select count(*) from participantes_conversas pc inner join mensagens m on m.conversa_id = pc.conversa_id "+
+			"where pc.usuario_id = ? and m.id > pc.lidoAte and m.remetente_id <> pc.usuario_id and m.deletadoEm is null", usuarioID).Scan(&naoLidas)
+	return naoLidas, erro
+}
+
+// BuscarIDsDosParticipantes traz os ids de todos os participantes da conversa
+func (repositorio Conversas) BuscarIDsDosParticipantes(conversaID uint64) ([]uint64, error) {
+	linhas, erro := repositorio.db.Query("select usuario_id from participantes_conversas where conversa_id = ?", conversaID)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var ids []uint64
+	for linhas.Next() {
+		var id uint64
+		if erro = linhas.Scan(&id); erro != nil {
+			return nil, erro
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// CriarMensagem insere a mensagem, move a conversa para o topo e marca ela como lida para o remetente.
+// São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Conversas) CriarMensagem(mensagem modelos.Mensagem) (uint64, error) {
+	resultado, erro := repositorio.db.Exec(
+		"insert into mensagens (conversa_id, remetente_id, conteudo) values (?, ?, ?)", mensagem.ConversaID, mensagem.RemetenteID, mensagem.Conteudo)
+	if erro != nil {
+		return 0, erro
+	}
+	mensagemID, erro := resultado.LastInsertId()
+	if erro != nil {
+		return 0, erro
+	}
+	if _, erro = repositorio.db.Exec("update conversas set atualizadoEm = now() where id = ?", mensagem.ConversaID); erro != nil {
+		return 0, erro
+	}
+	if _, erro = repositorio.db.Exec(
+		"update participantes_conversas set lidoAte = ? where conversa_id = ? and usuario_id = ?", mensagemID, mensagem.ConversaID, mensagem.RemetenteID); erro != nil {
+		return 0, erro
+	}
+	return uint64(mensagemID), nil
+}
+
+// BuscarMensagem traz uma mensagem pelo seu id, inclusive se ela estiver deletada
+func (repositorio Conversas) BuscarMensagem(mensagemID uint64) (modelos.Mensagem, error) {
+	linhas, erro := repositorio.db.Query(
+		"select "+colunasDeMensagem+" from mensagens m inner join usuarios u on u.id = m.remetente_id where m.id = ?", mensagemID)
+	if erro != nil {
+		return modelos.Mensagem{}, erro
+	}
+	defer linhas.Close()
+	var mensagem modelos.Mensagem
+	if linhas.Next() {
+		if mensagem, erro = escanearMensagem(linhas); erro != nil {
+			return modelos.Mensagem{}, erro
+		}
+	}
+	return mensagem, nil
+}
+
+// BuscarMensagens traz uma página das mensagens da conversa, das mais recentes para as mais antigas
+func (repositorio Conversas) BuscarMensagens(conversaID uint64, pagina, limite int) ([]modelos.Mensagem, error) {
+	linhas, erro := repositorio.db.Query(
+		"select "+colunasDeMensagem+" from mensagens m inner join usuarios u on u.id = m.remetente_id "+
+			"where m.conversa_id = ? order by m.id desc limit ? offset ?", conversaID, limite, (pagina-1)*limite)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var mensagens []modelos.Mensagem
+	for linhas.Next() {
+		mensagem, erro := escanearMensagem(linhas)
+		if erro != nil {
+			return nil, erro
+		}
+		mensagens = append(mensagens, mensagem)
+	}
+	return mensagens, nil
+}
+
+// MarcarComoLida marca todas as mensagens da conversa até agora como lidas por usuarioID
+func (repositorio Conversas) MarcarComoLida(conversaID, usuarioID uint64) error {
+	_, erro := repositorio.db.Exec(
+		"update participantes_conversas set lidoAte = (select coalesce(max(id), 0) from mensagens where conversa_id = ?) "+
+			"where conversa_id = ? and usuario_id = ?", conversaID, conversaID, usuarioID)
+	return erro
+}
+
+// DeletarMensagem apaga o conteúdo da mensagem, que continua na conversa marcada como deletada
+func (repositorio Conversas) DeletarMensagem(mensagemID uint64) error {
+	_, erro := repositorio.db.Exec(
+		"update mensagens set conteudo = '', deletadoEm = now() where id = ? and deletadoEm is null", mensagemID)
+	return erro
+}
+
+// escanearMensagem lê uma linha com as colunas de colunasDeMensagem
+func escanearMensagem(linhas *sql.Rows) (modelos.Mensagem, error) {
+	var mensagem modelos.Mensagem
+	erro := linhas.Scan(
+		&mensagem.ID,
+		&mensagem.ConversaID,
+		&mensagem.RemetenteID,
+		&mensagem.RemetenteNick,
+		&mensagem.Conteudo,
+		&mensagem.CriadoEm,
+		&mensagem.Deletada,
+	)
+	return mensagem, erro
+}
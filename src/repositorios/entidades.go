@@ -0,0 +1,146 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// entidadesJSON lê a coluna entidades das publicações, guardada como JSON
+type entidadesJSON struct {
+	destino *[]modelos.Entidade
+}
+
+// Scan implementa sql.Scanner
+func (entidades entidadesJSON) Scan(valor interface{}) error {
+	switch dados := valor.(type) {
+	case nil:
+		*entidades.destino = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(dados, entidades.destino)
+	case string:
+		return json.Unmarshal([]byte(dados), entidades.destino)
+	}
+	return fmt.Errorf("tipo inesperado para entidades: %T", valor)
+}
+
+// salvarEntidades resolve os nicks mencionados para ids, descarta as menções a quem não existe, guarda as
+// que sobraram na tabela de menções (para a linha do tempo de menções), as hashtags na tabela de hashtags
+// e as entidades na própria publicação. Quem passou a ser mencionado agora é notificado pelo autor.
+// Retorna as entidades resolvidas
+func (repositorio Publicacoes) salvarEntidades(publicacaoID uint64, publicacao modelos.Publicacao) ([]modelos.Entidade, error) {
+	entidades := publicacao.Entidades
+	idsPorNick := map[string]uint64{}
+	if nicks := modelos.NicksMencionados(entidades); len(nicks) > 0 {
+		argumentos := make([]interface{}, len(nicks))
+		for i, nick := range nicks {
+			argumentos[i] = nick
+		}
+		linhas, erro := repositorio.db.Query("select id, nick from usuarios where nick in ("+marcadores(len(nicks))+")", argumentos...)
+		if erro != nil {
+			return nil, erro
+		}
+		for linhas.Next() {
+			var usuarioID uint64
+			var nick string
+			if erro = linhas.Scan(&usuarioID, &nick); erro != nil {
+				linhas.Close()
+				return nil, erro
+			}
+			idsPorNick[strings.ToLower(nick)] = usuarioID
+		}
+		linhas.Close()
+	}
+	var resolvidas []modelos.Entidade
+	for _, entidade := range entidades {
+		if entidade.Tipo == modelos.TipoMencao {
+			usuarioID, existe := idsPorNick[strings.ToLower(entidade.Texto)]
+			if !existe {
+				continue
+			}
+			entidade.UsuarioID = usuarioID
+		}
+		resolvidas = append(resolvidas, entidade)
+	}
+	//refazendo as menções do zero, já que numa edição elas podem ter mudado, lembrando de quem já era mencionado
+	jaMencionados, erro := repositorio.mencionados(publicacaoID)
+	if erro != nil {
+		return nil, erro
+	}
+	if _, erro := repositorio.db.Exec("delete from mencoes where publicacao_id = ?", publicacaoID); erro != nil {
+		return nil, erro
+	}
+	notificacoes := Notificacoes{repositorio.db, repositorio.pendentes}
+	for _, entidade := range resolvidas {
+		if entidade.Tipo != modelos.TipoMencao {
+			continue
+		}
+		resultado, erro := repositorio.db.Exec(
+			"insert ignore into mencoes (publicacao_id, usuario_id) values (?, ?)", publicacaoID, entidade.UsuarioID)
+		if erro != nil {
+			return nil, erro
+		}
+		//a mesma pessoa mencionada duas vezes só gera uma linha, e uma notificação
+		linhasAfetadas, erro := resultado.RowsAffected()
+		if erro != nil {
+			return nil, erro
+		}
+		if linhasAfetadas == 0 || jaMencionados[entidade.UsuarioID] {
+			continue
+		}
+		//quem não pode ver a publicação não é avisado de que foi mencionado nela
+		visivel, erro := repositorio.visivel(publicacaoID, entidade.UsuarioID)
+		if erro != nil {
+			return nil, erro
+		}
+		if !visivel {
+			continue
+		}
+		if erro = notificacoes.Notificar(entidade.UsuarioID, publicacao.AutorID, modelos.NotificacaoMencao, &publicacaoID, nil,
+			publicacao.Titulo+"\n"+publicacao.Conteudo); erro != nil {
+			return nil, erro
+		}
+	}
+	//as hashtags levam o momento da publicação, não da edição, para os cálculos de em alta
+	if _, erro := repositorio.db.Exec("delete from hashtags_publicacoes where publicacao_id = ?", publicacaoID); erro != nil {
+		return nil, erro
+	}
+	for _, tag := range modelos.Hashtags(resolvidas) {
+		if _, erro := repositorio.db.Exec(
+			"insert into hashtags_publicacoes (publicacao_id, tag, criadoEm) select id, ?, criadoEm from publicacoes where id = ?", tag, publicacaoID); erro != nil {
+			return nil, erro
+		}
+	}
+	var valor interface{}
+	if len(resolvidas) > 0 {
+		dados, erro := json.Marshal(resolvidas)
+		if erro != nil {
+			return nil, erro
+		}
+		valor = string(dados)
+	}
+	if _, erro := repositorio.db.Exec("update publicacoes set entidades = ? where id = ?", valor, publicacaoID); erro != nil {
+		return nil, erro
+	}
+	return resolvidas, nil
+}
+
+// mencionados traz os ids de quem está mencionado na publicação
+func (repositorio Publicacoes) mencionados(publicacaoID uint64) (map[uint64]bool, error) {
+	linhas, erro := repositorio.db.Query("select usuario_id from mencoes where publicacao_id = ?", publicacaoID)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	mencionados := map[uint64]bool{}
+	for linhas.Next() {
+		var usuarioID uint64
+		if erro = linhas.Scan(&usuarioID); erro != nil {
+			return nil, erro
+		}
+		mencionados[usuarioID] = true
+	}
+	return mencionados, nil
+}
@@ -0,0 +1,103 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"database/sql"
+	"sort"
+	"time"
+)
+
+const (
+	//minimoDePublicacoesEmAlta evita que uma tag usada duas vezes depois de nenhuma vire assunto em alta
+	minimoDePublicacoesEmAlta = 3
+	//quantidadeDeHashtagsEmAlta é quantas tags ficam guardadas a cada cálculo
+	quantidadeDeHashtagsEmAlta = 50
+)
+
+// Hashtags representa o repositório das hashtags em alta
+type Hashtags struct {
+	db executor
+}
+
+// NovoRepositorioDeHashtags cria um repositorio de hashtags
+func NovoRepositorioDeHashtags(db *sql.DB) *Hashtags {
+	return &Hashtags{db}
+}
+
+// CalcularEmAlta compara o uso de cada tag na janela que termina em agora com a janela anterior de mesmo tamanho
+// e retorna as que mais aceleraram. A pontuação é o crescimento relativo ao uso anterior, então uma tag sempre
+// muito usada só fica em alta se passar a ser usada mais do que antes. Só contam as publicações públicas de contas
+// abertas, as que quem não segue ninguém pode ver
+func (repositorio Hashtags) CalcularEmAlta(agora time.Time, janela time.Duration) ([]modelos.HashtagEmAlta, error) {
+	inicioDaJanela := agora.Add(-janela)
+	linhas, erro := repositorio.db.Query(
+		"select h.tag, sum(h.criadoEm >= ?), sum(h.criadoEm < ?) from hashtags_publicacoes h "+
+			"inner join publicacoes p on p.id = h.publicacao_id inner join usuarios u on u.id = p.autor_id "+
+			"where h.criadoEm >= ? and h.criadoEm <= ? and p.deletadoEm is null and p.visibilidade = ? and not u.privado group by h.tag",
+		inicioDaJanela, inicioDaJanela, agora.Add(-2*janela), agora, modelos.VisibilidadePublica)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var hashtags []modelos.HashtagEmAlta
+	for linhas.Next() {
+		var hashtag modelos.HashtagEmAlta
+		if erro = linhas.Scan(&hashtag.Tag, &hashtag.Publicacoes, &hashtag.PublicacoesAntes); erro != nil {
+			return nil, erro
+		}
+		if hashtag.Publicacoes < minimoDePublicacoesEmAlta || hashtag.Publicacoes <= hashtag.PublicacoesAntes {
+			continue
+		}
+		hashtag.Pontuacao = float64(hashtag.Publicacoes-hashtag.PublicacoesAntes) / float64(hashtag.PublicacoesAntes+1)
+		hashtags = append(hashtags, hashtag)
+	}
+	sort.Slice(hashtags, func(i, j int) bool {
+		if hashtags[i].Pontuacao != hashtags[j].Pontuacao {
+			return hashtags[i].Pontuacao > hashtags[j].Pontuacao
+		}
+		return hashtags[i].Publicacoes > hashtags[j].Publicacoes
+	})
+	if len(hashtags) > quantidadeDeHashtagsEmAlta {
+		hashtags = hashtags[:quantidadeDeHashtagsEmAlta]
+	}
+	return hashtags, nil
+}
+
+// SalvarEmAlta troca as hashtags em alta guardadas pelas recebidas.
+// São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Hashtags) SalvarEmAlta(hashtags []modelos.HashtagEmAlta) error {
+	if _, erro := repositorio.db.Exec("delete from hashtags_em_alta"); erro != nil {
+		return erro
+	}
+	statement, erro := repositorio.db.Prepare(
+		"insert into hashtags_em_alta (tag, pontuacao, publicacoes, publicacoesAntes) values (?,?,?,?)")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+	for _, hashtag := range hashtags {
+		if _, erro = statement.Exec(hashtag.Tag, hashtag.Pontuacao, hashtag.Publicacoes, hashtag.PublicacoesAntes); erro != nil {
+			return erro
+		}
+	}
+	return nil
+}
+
+// BuscarEmAlta traz as hashtags em alta do último cálculo, da mais acelerada para a menos
+func (repositorio Hashtags) BuscarEmAlta(limite int) ([]modelos.HashtagEmAlta, error) {
+	linhas, erro := repositorio.db.Query(
+		"select tag, publicacoes, publicacoesAntes, pontuacao from hashtags_em_alta order by pontuacao desc, publicacoes desc limit ?", limite)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var hashtags []modelos.HashtagEmAlta
+	for linhas.Next() {
+		var hashtag modelos.HashtagEmAlta
+		if erro = linhas.Scan(&hashtag.Tag, &hashtag.Publicacoes, &hashtag.PublicacoesAntes, &hashtag.Pontuacao); erro != nil {
+			return nil, erro
+		}
+		hashtags = append(hashtags, hashtag)
+	}
+	return hashtags, nil
+}
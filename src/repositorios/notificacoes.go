@@ -0,0 +1,150 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"database/sql"
+	"fmt"
+)
+
+// AoNotificar recebe cada notificação criada, depois que ela já está confirmada no banco, para entregar em tempo real
+var AoNotificar = func(notificacao modelos.Notificacao) {}
+
+// Notificacoes representa o repositório de notificações
+type Notificacoes struct {
+	db executor
+	//pendentes guarda as notificações criadas dentro de uma transação até ela ser confirmada
+	pendentes *[]modelos.Notificacao
+}
+
+// NovoRepositorioDeNotificacoes cria um repositorio de notificações
+func NovoRepositorioDeNotificacoes(db *sql.DB) *Notificacoes {
+	return &Notificacoes{db: db}
+}
+
+// Notificar registra que atorID fez algo do tipo recebido para usuarioID. Se já existe uma notificação não lida
+// do mesmo tipo sobre o mesmo alvo (a publicação, ou o comentário quando houver) atorID é somado a ela, e quantidade
+// conta as pessoas diferentes: quem repete o evento, como curtir de novo depois de descurtir, não muda nada.
+// texto é o que atorID escreveu no evento, vazio quando não houver, e é conferido com as palavras silenciadas.
+// Ninguém é notificado das próprias ações, das de quem tem bloqueio com ele nem do que silenciou
+func (repositorio Notificacoes) Notificar(usuarioID, atorID uint64, tipo string, publicacaoID, comentarioID *uint64, texto string) error {
+	if usuarioID == atorID {
+		return nil
+	}
+	usuarios := Usuarios{repositorio.db}
+	bloqueado, erro := usuarios.Bloqueado(usuarioID, atorID)
+	if erro != nil || bloqueado {
+		return erro
+	}
+	silenciamentos, erro := usuarios.buscarSilenciamentos(usuarioID)
+	if erro != nil || silenciamentos.Silencia(atorID, texto) {
+		return erro
+	}
+	//a chave identifica o que é agrupado, o índice único sobre ela só vale enquanto a notificação não é lida
+	chave := tipo
+	if comentarioID != nil {
+		chave = fmt.Sprintf("%s:c%d", tipo, *comentarioID)
+	} else if publicacaoID != nil {
+		chave = fmt.Sprintf("%s:p%d", tipo, *publicacaoID)
+	}
+	//com last_insert_id(id) o id volta tanto da notificação criada quanto da que já estava aberta
+	statement, erro := repositorio.db.Prepare(
+		"insert into notificacoes (usuario_id, tipo, chave, publicacao_id, comentario_id, ultimo_ator_id) values (?,?,?,?,?,?) " +
+			"on duplicate key update id = last_insert_id(id)")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+	resultado, erro := statement.Exec(usuarioID, tipo, chave, publicacaoID, comentarioID, atorID)
+	if erro != nil {
+		return erro
+	}
+	notificacaoID, erro := resultado.LastInsertId()
+	if erro != nil {
+		return erro
+	}
+	resultado, erro = repositorio.db.Exec(
+		"insert ignore into notificacoes_atores (notificacao_id, ator_id) values (?, ?)", notificacaoID, atorID)
+	if erro != nil {
+		return erro
+	}
+	if linhasAfetadas, erro := resultado.RowsAffected(); erro != nil || linhasAfetadas == 0 {
+		return erro
+	}
+	if _, erro = repositorio.db.Exec(
+		"update notificacoes set quantidade = (select count(*) from notificacoes_atores where notificacao_id = ?), "+
+			"ultimo_ator_id = ?, atualizadoEm = now() where id = ?", notificacaoID, atorID, notificacaoID); erro != nil {
+		return erro
+	}
+	notificacao := modelos.Notificacao{
+		UsuarioID:    usuarioID,
+		Tipo:         tipo,
+		PublicacaoID: publicacaoID,
+		ComentarioID: comentarioID,
+		UltimoAtorID: atorID,
+	}
+	if repositorio.pendentes != nil {
+		*repositorio.pendentes = append(*repositorio.pendentes, notificacao)
+	} else {
+		AoNotificar(notificacao)
+	}
+	return nil
+}
+
+// Buscar traz uma página das notificações de usuarioID, das atualizadas mais recentemente para as mais antigas
+func (repositorio Notificacoes) Buscar(usuarioID uint64, pagina, limite int) ([]modelos.Notificacao, error) {
+	linhas, erro := repositorio.db.Query(
+		"select n.id, n.usuario_id, n.tipo, n.publicacao_id, n.comentario_id, n.quantidade, n.ultimo_ator_id, u.nick, n.lida, n.criadoEm, n.atualizadoEm "+
+			"from notificacoes n inner join usuarios u on u.id = n.ultimo_ator_id "+
+			"where n.usuario_id = ? order by n.atualizadoEm desc, n.id desc limit ? offset ?",
+		usuarioID, limite, (pagina-1)*limite)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var notificacoes []modelos.Notificacao
+	for linhas.Next() {
+		var notificacao modelos.Notificacao
+		if erro = linhas.Scan(
+			&notificacao.ID,
+			&notificacao.UsuarioID,
+			&notificacao.Tipo,
+			&notificacao.PublicacaoID,
+			&notificacao.ComentarioID,
+			&notificacao.Quantidade,
+			&notificacao.UltimoAtorID,
+			&notificacao.UltimoAtorNick,
+			&notificacao.Lida,
+			&notificacao.CriadoEm,
+			&notificacao.AtualizadoEm,
+		); erro != nil {
+			return nil, erro
+		}
+		notificacoes = append(notificacoes, notificacao)
+	}
+	return notificacoes, nil
+}
+
+// ContarNaoLidas traz quantas notificações de usuarioID ainda não foram lidas
+func (repositorio Notificacoes) ContarNaoLidas(usuarioID uint64) (uint64, error) {
+	var naoLidas uint64
+	erro := repositorio.db.QueryRow("select count(*) from notificacoes where usuario_id = ? and lida = false", usuarioID).Scan(&naoLidas)
+	return naoLidas, erro
+}
+
+// MarcarComoLida marca uma notificação de usuarioID como lida. O bool retornado diz se a notificação existe
+func (repositorio Notificacoes) MarcarComoLida(notificacaoID, usuarioID uint64) (bool, error) {
+	var existe bool
+	erro := repositorio.db.QueryRow(
+		"select count(*) > 0 from notificacoes where id = ? and usuario_id = ?", notificacaoID, usuarioID).Scan(&existe)
+	if erro != nil || !existe {
+		return false, erro
+	}
+	_, erro = repositorio.db.Exec("update notificacoes set lida = true where id = ? and usuario_id = ?", notificacaoID, usuarioID)
+	return true, erro
+}
+
+// MarcarTodasComoLidas marca todas as notificações de usuarioID como lidas
+func (repositorio Notificacoes) MarcarTodasComoLidas(usuarioID uint64) error {
+	_, erro := repositorio.db.Exec("update notificacoes set lida = true where usuario_id = ? and lida = false", usuarioID)
+	return erro
+}
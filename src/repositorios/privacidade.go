@@ -0,0 +1,105 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"database/sql"
+	"fmt"
+)
+
+// contaVisivel é o filtro que deixa só as linhas em que coluna é um usuário cujas publicações e seguidores quem
+// está vendo pode ver: ele mesmo, uma conta pública ou uma conta privada que ele segue.
+// Usa dois argumentos, ambos o id de quem está vendo
+func contaVisivel(coluna string) string {
+	return fmt.Sprintf(
+		"(%[1]s = ? or not exists (select 1 from usuarios uv where uv.id = %[1]s and uv.privado) or exists (select 1 from seguidores sv where sv.usuario_id = %[1]s and sv.seguidor_id = ?))", coluna)
+}
+
+// PodeVer diz se usuarioLogadoID pode ver as publicações, os seguidores e quem usuarioID segue
+func (repositorio Usuarios) PodeVer(usuarioLogadoID, usuarioID uint64) (bool, error) {
+	var podeVer bool
+	erro := repositorio.db.QueryRow(
+		"select id = ? or not privado or exists (select 1 from seguidores where usuario_id = id and seguidor_id = ?) from usuarios where id = ?",
+		usuarioLogadoID, usuarioLogadoID, usuarioID).Scan(&podeVer)
+	//usuário que não existe não tem nada escondido, quem chamou decide o que fazer com ele
+	if erro == sql.ErrNoRows {
+		return true, nil
+	}
+	return podeVer, erro
+}
+
+// AtualizarPrivacidade torna a conta privada ou pública. Ao virar pública os pedidos pendentes são aprovados,
+// já que ninguém mais precisa de aprovação. São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Usuarios) AtualizarPrivacidade(usuarioID uint64, privado bool) error {
+	if _, erro := repositorio.db.Exec("update usuarios set privado = ? where id = ?", privado, usuarioID); erro != nil {
+		return erro
+	}
+	if !privado {
+		pedidos, erro := repositorio.BuscarPedidosParaSeguir(usuarioID)
+		if erro != nil {
+			return erro
+		}
+		for _, pedido := range pedidos {
+			if _, erro = repositorio.AprovarPedidoParaSeguir(usuarioID, pedido.ID); erro != nil {
+				return erro
+			}
+		}
+	}
+	invalidarCache(repositorio.db, chaveUsuario(usuarioID))
+	invalidarFeeds(repositorio.db)
+	return nil
+}
+
+// PedirParaSeguir registra que seguidorID quer seguir a conta privada usuarioID. Pedir de novo não muda nada,
+// o bool retornado diz se o pedido é novo
+func (repositorio Usuarios) PedirParaSeguir(usuarioID, seguidorID uint64) (bool, error) {
+	resultado, erro := repositorio.db.Exec(
+		"insert ignore into pedidos_para_seguir (usuario_id, seguidor_id) values (?, ?)", usuarioID, seguidorID)
+	if erro != nil {
+		return false, erro
+	}
+	linhasAfetadas, erro := resultado.RowsAffected()
+	return linhasAfetadas > 0, erro
+}
+
+// BuscarPedidosParaSeguir traz quem pediu para seguir usuarioID e ainda espera resposta, do pedido mais antigo para o mais recente
+func (repositorio Usuarios) BuscarPedidosParaSeguir(usuarioID uint64) ([]modelos.Usuario, error) {
+	linhas, erro := repositorio.db.Query(
+		"select u.id, u.nome, u.nick from pedidos_para_seguir p inner join usuarios u on u.id = p.seguidor_id where p.usuario_id = ? order by p.criadoEm, u.id", usuarioID)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var usuarios []modelos.Usuario
+	for linhas.Next() {
+		var usuario modelos.Usuario
+		if erro = linhas.Scan(&usuario.ID, &usuario.Nome, &usuario.Nick); erro != nil {
+			return nil, erro
+		}
+		usuarios = append(usuarios, usuario)
+	}
+	return usuarios, nil
+}
+
+// AprovarPedidoParaSeguir faz seguidorID passar a seguir usuarioID se havia um pedido dele. O bool retornado diz se havia.
+// São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Usuarios) AprovarPedidoParaSeguir(usuarioID, seguidorID uint64) (bool, error) {
+	existia, erro := repositorio.RejeitarPedidoParaSeguir(usuarioID, seguidorID)
+	if erro != nil || !existia {
+		return false, erro
+	}
+	if _, erro = repositorio.Seguir(usuarioID, seguidorID); erro != nil {
+		return false, erro
+	}
+	return true, nil
+}
+
+// RejeitarPedidoParaSeguir apaga o pedido de seguidorID para seguir usuarioID. O bool retornado diz se havia pedido
+func (repositorio Usuarios) RejeitarPedidoParaSeguir(usuarioID, seguidorID uint64) (bool, error) {
+	resultado, erro := repositorio.db.Exec(
+		"delete from pedidos_para_seguir where usuario_id = ? and seguidor_id = ?", usuarioID, seguidorID)
+	if erro != nil {
+		return false, erro
+	}
+	linhasAfetadas, erro := resultado.RowsAffected()
+	return linhasAfetadas > 0, erro
+}
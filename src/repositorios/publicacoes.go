@@ -1,30 +1,48 @@
 package repositorios
 
 import (
+	"api/src/busca"
 	"api/src/modelos"
 	"database/sql"
+	"errors"
+	"time"
 )
 
+// ErroPublicacaoNaoEncontrada é retornado ao agir sobre uma publicação que não existe, foi deletada ou o usuário não pode ver
+var ErroPublicacaoNaoEncontrada = errors.New("publicação não encontrada")
+
+// ErroPublicacaoNaoRepublicavel é retornado ao republicar uma publicação que o usuário vê mas não é pública ou é de uma conta privada
+var ErroPublicacaoNaoRepublicavel = errors.New("só publicações públicas de contas abertas podem ser republicadas")
+
+// colunasDePublicacao são as colunas lidas em toda busca de publicações, na ordem em que escanearPublicacao espera.
+// As queries precisam dar o apelido p para publicacoes e u para o autor em usuarios
+const colunasDePublicacao = "p.id, p.titulo, p.conteudo, p.autor_id, p.curtidas, p.comentarios, p.republicacoes, p.citacoes, p.citada_id, p.visibilidade, p.audiencia_id, p.criadoEm, p.versao, p.editadoEm, p.entidades, u.nick"
+
 // Publicacoes representa o repositório de publicações
 type Publicacoes struct {
-	db *sql.DB
+	db executor
+	//pendentes são as notificações (de menções) esperando a transação ser confirmada, como em Notificacoes
+	pendentes *[]modelos.Notificacao
 }
 
 // NovoRepositorioDePublicacoes cria um repositorio de publicações
 func NovoRepositorioDePublicacoes(db *sql.DB) *Publicacoes {
-	return &Publicacoes{db}
+	return &Publicacoes{db: db}
 }
 
-// Criar insere uma publicação no banco de dados
+// Criar insere uma publicação no banco de dados, junto com a primeira revisão dela e os anexos, e conta mais uma
+// publicação para o autor. Se for uma citação também incrementa o contador de citações da publicação citada.
+// São vários comandos, então deve ser chamado dentro de uma transação
 func (repositorio Publicacoes) Criar(publicacao modelos.Publicacao) (uint64, error) {
 	//criando declaração de inserção e a executando
 	statement, erro := repositorio.db.Prepare(
-		"insert into publicacoes (titulo,conteudo,autor_id) values (?,?,?)")
+		"insert into publicacoes (titulo,conteudo,autor_id,citada_id,visibilidade,audiencia_id) values (?,?,?,?,?,?)")
 	if erro != nil {
 		return 0, erro
 	}
 	defer statement.Close()
-	resultado, erro := statement.Exec(publicacao.Titulo, publicacao.Conteudo, publicacao.AutorID)
+	resultado, erro := statement.Exec(publicacao.Titulo, publicacao.Conteudo, publicacao.AutorID, publicacao.CitadaID,
+		publicacao.Visibilidade, publicacao.AudienciaID)
 	if erro != nil {
 		return 0, erro
 	}
@@ -32,99 +50,316 @@ func (repositorio Publicacoes) Criar(publicacao modelos.Publicacao) (uint64, err
 	if erro != nil {
 		return 0, erro
 	}
+	if erro = repositorio.criarRevisao(uint64(ultimoIDInserido), publicacao); erro != nil {
+		return 0, erro
+	}
+	if _, erro = repositorio.salvarEntidades(uint64(ultimoIDInserido), publicacao); erro != nil {
+		return 0, erro
+	}
+	if erro = (Anexos{repositorio.db}).vincular(uint64(ultimoIDInserido), publicacao.AutorID, publicacao.AnexosIDs); erro != nil {
+		return 0, erro
+	}
+	if erro = repositorio.ajustarContadorDePublicacoes(publicacao.AutorID, 1); erro != nil {
+		return 0, erro
+	}
+	if publicacao.CitadaID != nil {
+		if _, erro = repositorio.db.Exec("update publicacoes set citacoes = citacoes + 1 where id = ?", *publicacao.CitadaID); erro != nil {
+			return 0, erro
+		}
+		invalidarCache(repositorio.db, chavePublicacao(*publicacao.CitadaID))
+	}
+	invalidarFeeds(repositorio.db)
+	indexarPublicacao(repositorio.db, busca.Documento{
+		PublicacaoID: uint64(ultimoIDInserido),
+		AutorID:      publicacao.AutorID,
+		Titulo:       publicacao.Titulo,
+		Conteudo:     publicacao.Conteudo,
+		CriadoEm:     time.Now(),
+	})
 	//retorna o id da publicação inserido
 	return uint64(ultimoIDInserido), nil
 }
 
-// BuscarPorID insere uma publicação no banco de dados
-func (repositorio Publicacoes) BuscarPorID(publicacaoID uint64) (modelos.Publicacao, error) {
+// BuscarPorID traz uma publicação pelo seu id como vista por usuarioLogadoID, passando antes pelo cache.
+// Publicações deletadas ou que usuarioLogadoID não pode ver não são encontradas
+func (repositorio Publicacoes) BuscarPorID(publicacaoID, usuarioLogadoID uint64) (modelos.Publicacao, error) {
+	var publicacao modelos.Publicacao
+	if !buscarDoCache(repositorio.db, chavePublicacao(publicacaoID), &publicacao) {
+		var erro error
+		if publicacao, erro = repositorio.buscarPorID(publicacaoID); erro != nil {
+			return modelos.Publicacao{}, erro
+		}
+	}
+	if publicacao.ID == 0 {
+		return publicacao, nil
+	}
+	//o cache é igual para todo mundo, então quem pode ver é conferido depois dele
+	if usuarioLogadoID != 0 {
+		visivel, erro := repositorio.visivel(publicacao.ID, usuarioLogadoID)
+		if erro != nil {
+			return modelos.Publicacao{}, erro
+		}
+		if !visivel {
+			return modelos.Publicacao{}, nil
+		}
+	}
+	publicacoes := []modelos.Publicacao{publicacao}
+	if erro := repositorio.preencherDadosDoLeitor(publicacoes, usuarioLogadoID); erro != nil {
+		return modelos.Publicacao{}, erro
+	}
+	return publicacoes[0], nil
+}
+
+// buscarPorID traz a publicação do banco e guarda no cache, sem os dados que dependem de quem está vendo
+func (repositorio Publicacoes) buscarPorID(publicacaoID uint64) (modelos.Publicacao, error) {
+	var publicacao modelos.Publicacao
 	//selecionando publicacao que tenha o id recebido
 	linha, erro := repositorio.db.Query(
-		"select p.*, u.nick from publicacoes p inner join usuarios u on u.id = p.autor_id where p.id=?", publicacaoID)
+		"select "+colunasDePublicacao+" from publicacoes p inner join usuarios u on u.id = p.autor_id where p.id=? and p.deletadoEm is null", publicacaoID)
 	if erro != nil {
 		return modelos.Publicacao{}, erro
 	}
 	defer linha.Close()
 	//passando os dados da publicacao para uma struct e a retornando
-	var publicacao modelos.Publicacao
-	if linha.Next() {
-		if erro = linha.Scan(
-			&publicacao.ID,
-			&publicacao.Titulo,
-			&publicacao.Conteudo,
-			&publicacao.AutorID,
-			&publicacao.Curtidas,
-			&publicacao.CriadoEm,
-			&publicacao.AutorNick,
-		); erro != nil {
-			return modelos.Publicacao{}, erro
-		}
+	if !linha.Next() {
+		return publicacao, linha.Err()
+	}
+	if publicacao, erro = escanearPublicacao(linha); erro != nil {
+		return modelos.Publicacao{}, erro
+	}
+	//fechando antes de buscar os anexos, dentro de uma transação só pode haver uma consulta aberta
+	linha.Close()
+	publicacoes := []modelos.Publicacao{publicacao}
+	if erro = (Anexos{repositorio.db}).preencherPublicacoes(publicacoes); erro != nil {
+		return modelos.Publicacao{}, erro
 	}
-	return publicacao, nil
+	salvarNoCache(repositorio.db, chavePublicacao(publicacaoID), publicacoes[0])
+	return publicacoes[0], nil
 }
 
-// Buscar traz todas as publicações do usuario com usuarioID e de todos os usuários que ele segue
+// Buscar traz todas as publicações do usuario com usuarioID e de todos os usuários que ele segue, junto com as
+// republicadas por eles, passando antes pelo cache. Cada publicação aparece uma vez só, na posição do
+// acontecimento mais recente: a criação dela ou a última republicação, que fica como atribuição.
+// O que o usuário silenciou fica de fora
 func (repositorio Publicacoes) Buscar(usuarioID uint64) ([]modelos.Publicacao, error) {
-	//selecionando dados da tabela
-	linhas, erro := repositorio.db.Query("select distinct p.*, u.nick from publicacoes p inner join usuarios u on u.id = p.autor_id left join seguidores s on p.autor_id = s.usuario_id where u.id=? or s.seguidor_id=? order by 1 desc", usuarioID, usuarioID)
+	chave := chaveFeed(usuarioID)
+	var publicacoes []modelos.Publicacao
+	if buscarDoCache(repositorio.db, chave, &publicacoes) {
+		return repositorio.prepararFeed(publicacoes, usuarioID)
+	}
+	//juntando as publicações e as republicações de quem aparece no feed, cada uma com o momento em que aconteceu
+	linhas, erro := repositorio.db.Query(
+		"select "+colunasDePublicacao+", f.republicador_id, coalesce(ur.nick, '') from ("+
+			"select id as publicacao_id, criadoEm as momento, null as republicador_id from publicacoes "+
+			"where autor_id = ? or autor_id in (select usuario_id from seguidores where seguidor_id = ?) "+
+			"union all "+
+			"select publicacao_id, criadoEm, usuario_id from republicacoes "+
+			"where usuario_id = ? or usuario_id in (select usuario_id from seguidores where seguidor_id = ?)"+
+			") f inner join publicacoes p on p.id = f.publicacao_id inner join usuarios u on u.id = p.autor_id "+
+			"left join usuarios ur on ur.id = f.republicador_id "+
+			"where p.deletadoEm is null and "+publicacaoVisivel("p")+" order by f.momento desc, p.id desc",
+		argumentos(usuarioID, usuarioID, usuarioID, usuarioID, visibilidadePara(usuarioID))...)
 	if erro != nil {
 		return nil, erro
 	}
 	defer linhas.Close()
-	//passando os dados das publicações para um slice de structs e o retornando
-	var publicacoes []modelos.Publicacao
+	//passando os dados das publicações para um slice de structs, ficando só com a primeira vez que cada uma aparece
+	vistas := map[uint64]bool{}
 	for linhas.Next() {
 		var publicacao modelos.Publicacao
-		if erro = linhas.Scan(
-			&publicacao.ID,
-			&publicacao.Titulo,
-			&publicacao.Conteudo,
-			&publicacao.AutorID,
-			&publicacao.Curtidas,
-			&publicacao.CriadoEm,
-			&publicacao.AutorNick,
-		); erro != nil {
+		var republicadorID sql.NullInt64
+		if erro = linhas.Scan(append(destinosDePublicacao(&publicacao), &republicadorID, &publicacao.RepublicadaPorNick)...); erro != nil {
 			return nil, erro
 		}
+		if vistas[publicacao.ID] {
+			continue
+		}
+		vistas[publicacao.ID] = true
+		publicacao.RepublicadaPorID = uint64(republicadorID.Int64)
 		publicacoes = append(publicacoes, publicacao)
 	}
-	return publicacoes, nil
+	if erro = (Anexos{repositorio.db}).preencherPublicacoes(publicacoes); erro != nil {
+		return nil, erro
+	}
+	salvarNoCache(repositorio.db, chave, publicacoes)
+	return repositorio.prepararFeed(publicacoes, usuarioID)
 }
 
-// Atualizar altera os dados de uma publicação no banco de dados
+// prepararFeed tira do feed as publicações e republicações silenciadas por usuarioID e completa o resto com os
+// dados do leitor. Os silenciamentos ficam fora do cache porque podem acabar sozinhos a qualquer momento
+func (repositorio Publicacoes) prepararFeed(publicacoes []modelos.Publicacao, usuarioID uint64) ([]modelos.Publicacao, error) {
+	silenciamentos, erro := Usuarios{repositorio.db}.buscarSilenciamentos(usuarioID)
+	if erro != nil {
+		return nil, erro
+	}
+	var visiveis []modelos.Publicacao
+	for _, publicacao := range publicacoes {
+		//as próprias publicações nunca são silenciadas
+		if publicacao.AutorID != usuarioID && silenciamentos.Silencia(publicacao.AutorID, publicacao.Titulo+"\n"+publicacao.Conteudo) {
+			continue
+		}
+		if silenciamentos.Contas[publicacao.RepublicadaPorID] {
+			continue
+		}
+		visiveis = append(visiveis, publicacao)
+	}
+	return visiveis, repositorio.preencherDadosDoLeitor(visiveis, usuarioID)
+}
+
+// Atualizar altera os dados de uma publicação no banco de dados, marca ela como editada e guarda a nova revisão.
+// Se publicacao.Versao for diferente de 0 a atualização só acontece se a versão salva for a mesma, caso contrário retorna ErroVersaoDesatualizada.
+// São dois comandos, então deve ser chamado dentro de uma transação
 func (repositorio Publicacoes) Atualizar(publicacaoID uint64, publicacao modelos.Publicacao) error {
 	//criando declaração de atualização e a executando
 	statement, erro := repositorio.db.Prepare(
-		"update publicacoes set titulo = ?, conteudo = ? where id = ?")
+		"update publicacoes set titulo = ?, conteudo = ?, visibilidade = ?, audiencia_id = ?, editadoEm = now(), versao = versao + 1 " +
+			"where id = ? and deletadoEm is null and (? = 0 or versao = ?)")
 	if erro != nil {
 		return erro
 	}
 	defer statement.Close()
-	if _, erro = statement.Exec(publicacao.Titulo, publicacao.Conteudo, publicacaoID); erro != nil {
+	resultado, erro := statement.Exec(publicacao.Titulo, publicacao.Conteudo, publicacao.Visibilidade, publicacao.AudienciaID,
+		publicacaoID, publicacao.Versao, publicacao.Versao)
+	if erro != nil {
+		return erro
+	}
+	if erro = verificarVersao(resultado, publicacao.Versao); erro != nil {
 		return erro
 	}
+	if erro = repositorio.criarRevisao(publicacaoID, publicacao); erro != nil {
+		return erro
+	}
+	if _, erro = repositorio.salvarEntidades(publicacaoID, publicacao); erro != nil {
+		return erro
+	}
+	invalidarCache(repositorio.db, chavePublicacao(publicacaoID))
+	invalidarFeeds(repositorio.db)
+	indexarPublicacao(repositorio.db, busca.Documento{PublicacaoID: publicacaoID, Titulo: publicacao.Titulo, Conteudo: publicacao.Conteudo})
 	return nil
 }
 
-// Deletar deleta os dados de uma publicação no banco de dados
+// Deletar marca a publicação como deletada. Ela some de todas as buscas mas continua no banco
+// (junto com as revisões) para moderação, até ser apagada de vez por PurgarDeletadas.
+// Também decrementa o contador de publicações do autor e, se for uma citação, o da citada,
+// então deve ser chamado dentro de uma transação
 func (repositorio Publicacoes) Deletar(publicacaoID uint64) error {
 	//criando declaração de atualização e a executando
 	statement, erro := repositorio.db.Prepare(
-		"delete from publicacoes where id = ?")
+		"update publicacoes set deletadoEm = now() where id = ? and deletadoEm is null")
 	if erro != nil {
 		return erro
 	}
 	defer statement.Close()
-	if _, erro = statement.Exec(publicacaoID); erro != nil {
+	resultado, erro := statement.Exec(publicacaoID)
+	if erro != nil {
+		return erro
+	}
+	if linhasAfetadas, erro := resultado.RowsAffected(); erro != nil || linhasAfetadas == 0 {
 		return erro
 	}
+	//uma citação deletada deixa de contar para a publicação citada, e toda publicação deletada para o autor
+	var citadaID sql.NullInt64
+	var autorID uint64
+	if erro = repositorio.db.QueryRow("select citada_id, autor_id from publicacoes where id = ?", publicacaoID).Scan(&citadaID, &autorID); erro != nil {
+		return erro
+	}
+	if erro = repositorio.ajustarContadorDePublicacoes(autorID, -1); erro != nil {
+		return erro
+	}
+	if citadaID.Valid {
+		if _, erro = repositorio.db.Exec(
+			"update publicacoes set citacoes = CASE WHEN citacoes > 0 THEN citacoes - 1 ELSE citacoes END where id = ?", citadaID.Int64); erro != nil {
+			return erro
+		}
+		invalidarCache(repositorio.db, chavePublicacao(uint64(citadaID.Int64)))
+	}
+	invalidarCache(repositorio.db, chavePublicacao(publicacaoID))
+	invalidarFeeds(repositorio.db)
+	removerPublicacaoDoIndice(repositorio.db, publicacaoID)
 	return nil
 }
 
-// BuscarPorUsuario traz todas publicacoes de um usuario do banco de dados
-func (repositorio Publicacoes) BuscarPorUsuario(usuarioID uint64) ([]modelos.Publicacao, error) {
+// PurgarDeletadas apaga de vez as publicações deletadas antes de limite e retorna quantas foram apagadas
+func (repositorio Publicacoes) PurgarDeletadas(limite time.Time) (int64, error) {
+	statement, erro := repositorio.db.Prepare("delete from publicacoes where deletadoEm < ?")
+	if erro != nil {
+		return 0, erro
+	}
+	defer statement.Close()
+	resultado, erro := statement.Exec(limite)
+	if erro != nil {
+		return 0, erro
+	}
+	return resultado.RowsAffected()
+}
+
+// BuscarHistorico traz todas as revisões de uma publicação, da mais antiga para a mais recente
+func (repositorio Publicacoes) BuscarHistorico(publicacaoID uint64) ([]modelos.Revisao, error) {
+	linhas, erro := repositorio.db.Query(
+		"select id, publicacao_id, titulo, conteudo, criadoEm from revisoes_publicacoes where publicacao_id = ? order by id", publicacaoID)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var revisoes []modelos.Revisao
+	for linhas.Next() {
+		var revisao modelos.Revisao
+		if erro = linhas.Scan(
+			&revisao.ID,
+			&revisao.PublicacaoID,
+			&revisao.Titulo,
+			&revisao.Conteudo,
+			&revisao.CriadoEm,
+		); erro != nil {
+			return nil, erro
+		}
+		revisoes = append(revisoes, revisao)
+	}
+	return revisoes, nil
+}
+
+// BuscarPorIDs traz as publicações com os ids recebidos como vistas por usuarioLogadoID, na mesma ordem dos ids.
+// Ids que não existem ou que usuarioLogadoID não pode ver são ignorados
+func (repositorio Publicacoes) BuscarPorIDs(publicacoesIDs []uint64, usuarioLogadoID uint64) ([]modelos.Publicacao, error) {
+	if len(publicacoesIDs) == 0 {
+		return nil, nil
+	}
+	ids := make([]interface{}, len(publicacoesIDs))
+	for i, publicacaoID := range publicacoesIDs {
+		ids[i] = publicacaoID
+	}
+	linhas, erro := repositorio.db.Query(
+		"select "+colunasDePublicacao+" from publicacoes p inner join usuarios u on u.id = p.autor_id where p.id in ("+marcadores(len(publicacoesIDs))+") and p.deletadoEm is null and "+publicacaoVisivel("p"),
+		argumentos(ids, visibilidadePara(usuarioLogadoID))...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	//guardando por id para depois devolver na ordem pedida
+	porID := make(map[uint64]modelos.Publicacao, len(publicacoesIDs))
+	for linhas.Next() {
+		publicacao, erro := escanearPublicacao(linhas)
+		if erro != nil {
+			return nil, erro
+		}
+		porID[publicacao.ID] = publicacao
+	}
+	var publicacoes []modelos.Publicacao
+	for _, publicacaoID := range publicacoesIDs {
+		if publicacao, existe := porID[publicacaoID]; existe {
+			publicacoes = append(publicacoes, publicacao)
+		}
+	}
+	return publicacoes, repositorio.completar(publicacoes, usuarioLogadoID)
+}
+
+// BuscarPorUsuario traz todas publicacoes de um usuario do banco de dados como vistas por usuarioLogadoID.
+// Com bloqueio entre os dois, ou se a conta for privada e usuarioLogadoID não a seguir, não vem nenhuma
+func (repositorio Publicacoes) BuscarPorUsuario(usuarioID, usuarioLogadoID uint64) ([]modelos.Publicacao, error) {
 	//selecioando publicações
-	linhas, erro := repositorio.db.Query("select p.*, u.nick from publicacoes p join usuarios u on u.id = p.autor_id where p.autor_id=?", usuarioID)
+	linhas, erro := repositorio.db.Query(
+		"select "+colunasDePublicacao+" from publicacoes p join usuarios u on u.id = p.autor_id where p.autor_id=? and p.deletadoEm is null and "+publicacaoVisivel("p"),
+		argumentos(usuarioID, visibilidadePara(usuarioLogadoID))...)
 	if erro != nil {
 		return nil, erro
 	}
@@ -132,48 +367,307 @@ func (repositorio Publicacoes) BuscarPorUsuario(usuarioID uint64) ([]modelos.Pub
 	//passando os dados das publicações para um slice de structs e o retornando
 	var publicacoes []modelos.Publicacao
 	for linhas.Next() {
-		var publicacao modelos.Publicacao
-		if erro = linhas.Scan(
-			&publicacao.ID,
-			&publicacao.Titulo,
-			&publicacao.Conteudo,
-			&publicacao.AutorID,
-			&publicacao.Curtidas,
-			&publicacao.CriadoEm,
-			&publicacao.AutorNick,
-		); erro != nil {
+		publicacao, erro := escanearPublicacao(linhas)
+		if erro != nil {
 			return nil, erro
 		}
 		publicacoes = append(publicacoes, publicacao)
 	}
-	return publicacoes, nil
+	return publicacoes, repositorio.completar(publicacoes, usuarioLogadoID)
 
 }
 
-// Curtir incrementa o número de curtidas de uma publicação
-func (repositorio Publicacoes) Curtir(publicacaoID uint64) error {
-	//criando declaração de atualização e a executando
-	statement, erro := repositorio.db.Prepare("update publicacoes set curtidas = curtidas + 1 where id = ?")
+// BuscarMencoes traz uma página das publicações que mencionam usuarioID, das mais recentes para as mais antigas,
+// como vistas por usuarioLogadoID e só as que ele pode ver
+func (repositorio Publicacoes) BuscarMencoes(usuarioID, usuarioLogadoID uint64, pagina, limite int) ([]modelos.Publicacao, error) {
+	linhas, erro := repositorio.db.Query(
+		"select "+colunasDePublicacao+" from mencoes m inner join publicacoes p on p.id = m.publicacao_id inner join usuarios u on u.id = p.autor_id "+
+			"where m.usuario_id = ? and p.deletadoEm is null and "+publicacaoVisivel("p")+" order by p.id desc limit ? offset ?",
+		argumentos(usuarioID, visibilidadePara(usuarioLogadoID), limite, (pagina-1)*limite)...)
 	if erro != nil {
-		return erro
+		return nil, erro
+	}
+	defer linhas.Close()
+	var publicacoes []modelos.Publicacao
+	for linhas.Next() {
+		publicacao, erro := escanearPublicacao(linhas)
+		if erro != nil {
+			return nil, erro
+		}
+		publicacoes = append(publicacoes, publicacao)
+	}
+	return publicacoes, repositorio.completar(publicacoes, usuarioLogadoID)
+}
+
+// BuscarPorHashtag traz uma página das publicações com a tag, das mais recentes para as mais antigas,
+// como vistas por usuarioLogadoID e só as que ele pode ver
+func (repositorio Publicacoes) BuscarPorHashtag(tag string, usuarioLogadoID uint64, pagina, limite int) ([]modelos.Publicacao, error) {
+	linhas, erro := repositorio.db.Query(
+		"select "+colunasDePublicacao+" from hashtags_publicacoes h inner join publicacoes p on p.id = h.publicacao_id inner join usuarios u on u.id = p.autor_id "+
+			"where h.tag = ? and p.deletadoEm is null and "+publicacaoVisivel("p")+" order by p.id desc limit ? offset ?",
+		argumentos(tag, visibilidadePara(usuarioLogadoID), limite, (pagina-1)*limite)...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var publicacoes []modelos.Publicacao
+	for linhas.Next() {
+		publicacao, erro := escanearPublicacao(linhas)
+		if erro != nil {
+			return nil, erro
+		}
+		publicacoes = append(publicacoes, publicacao)
+	}
+	return publicacoes, repositorio.completar(publicacoes, usuarioLogadoID)
+}
+
+// Curtir registra que usuarioID curtiu a publicação e incrementa o contador dela. Curtir de novo não muda nada,
+// o bool retornado diz se a curtida é nova. Se a publicação não existir, tiver sido deletada ou usuarioID não puder vê-la
+// retorna ErroPublicacaoNaoEncontrada. São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Publicacoes) Curtir(publicacaoID, usuarioID uint64) (bool, error) {
+	//o select garante que só dá pra curtir publicação que existe, não foi deletada e usuarioID pode ver
+	statement, erro := repositorio.db.Prepare(
+		"insert ignore into curtidas (usuario_id, publicacao_id) select ?, p.id from publicacoes p where p.id = ? and p.deletadoEm is null and " + publicacaoVisivel("p"))
+	if erro != nil {
+		return false, erro
 	}
 	defer statement.Close()
-	if _, erro = statement.Exec(publicacaoID); erro != nil {
+	resultado, erro := statement.Exec(argumentos(usuarioID, publicacaoID, visibilidadePara(usuarioID))...)
+	if erro != nil {
+		return false, erro
+	}
+	linhasAfetadas, erro := resultado.RowsAffected()
+	if erro != nil {
+		return false, erro
+	}
+	if linhasAfetadas == 0 {
+		//nada inserido é curtida repetida ou publicação que o select não achou
+		visivel, erro := repositorio.visivel(publicacaoID, usuarioID)
+		if erro != nil {
+			return false, erro
+		}
+		if !visivel {
+			return false, ErroPublicacaoNaoEncontrada
+		}
+		return false, nil
+	}
+	if _, erro = repositorio.db.Exec("update publicacoes set curtidas = curtidas + 1 where id = ?", publicacaoID); erro != nil {
+		return false, erro
+	}
+	invalidarCache(repositorio.db, chavePublicacao(publicacaoID))
+	invalidarFeeds(repositorio.db)
+	return true, nil
+}
+
+// Descurtir desfaz a curtida de usuarioID e decrementa o contador da publicação. Se ele não tinha curtido nada muda,
+// o bool retornado diz se havia curtida. São dois comandos, então deve ser chamado dentro de uma transação
+func (repositorio Publicacoes) Descurtir(publicacaoID, usuarioID uint64) (bool, error) {
+	statement, erro := repositorio.db.Prepare("delete from curtidas where usuario_id = ? and publicacao_id = ?")
+	if erro != nil {
+		return false, erro
+	}
+	defer statement.Close()
+	resultado, erro := statement.Exec(usuarioID, publicacaoID)
+	if erro != nil {
+		return false, erro
+	}
+	if linhasAfetadas, erro := resultado.RowsAffected(); erro != nil || linhasAfetadas == 0 {
+		return false, erro
+	}
+	if _, erro = repositorio.db.Exec("update publicacoes set curtidas = CASE WHEN curtidas > 0 THEN curtidas - 1 ELSE curtidas END where id = ?", publicacaoID); erro != nil {
+		return false, erro
+	}
+	invalidarCache(repositorio.db, chavePublicacao(publicacaoID))
+	invalidarFeeds(repositorio.db)
+	return true, nil
+}
+
+// Republicar registra que usuarioID compartilhou a publicação como ela é, o que leva ela ao feed de quem o segue,
+// e incrementa o contador dela. Republicar de novo não muda nada, o bool retornado diz se a republicação é nova.
+// Se a publicação não existir, tiver sido deletada ou usuarioID não puder vê-la retorna ErroPublicacaoNaoEncontrada,
+// e se ele puder vê-la mas ela não puder ser republicada retorna ErroPublicacaoNaoRepublicavel.
+// São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Publicacoes) Republicar(publicacaoID, usuarioID uint64) (bool, error) {
+	//o select garante que só dá pra republicar publicação pública que existe, não foi deletada e usuarioID pode ver.
+	//Publicação de conta privada só pode ser republicada pelo autor, já que levaria ela para quem não é seguidor
+	statement, erro := repositorio.db.Prepare(
+		"insert ignore into republicacoes (usuario_id, publicacao_id) select ?, p.id from publicacoes p where p.id = ? and p.deletadoEm is null and " +
+			publicacaoVisivel("p") + " and p.visibilidade = '" + modelos.VisibilidadePublica + "'" +
+			" and (p.autor_id = ? or not exists (select 1 from usuarios ua where ua.id = p.autor_id and ua.privado))")
+	if erro != nil {
+		return false, erro
+	}
+	defer statement.Close()
+	resultado, erro := statement.Exec(argumentos(usuarioID, publicacaoID, visibilidadePara(usuarioID), usuarioID)...)
+	if erro != nil {
+		return false, erro
+	}
+	linhasAfetadas, erro := resultado.RowsAffected()
+	if erro != nil {
+		return false, erro
+	}
+	if linhasAfetadas == 0 {
+		//nada inserido é republicação repetida, publicação que ele não vê ou que não pode ser republicada
+		visivel, erro := repositorio.visivel(publicacaoID, usuarioID)
+		if erro != nil {
+			return false, erro
+		}
+		if !visivel {
+			return false, ErroPublicacaoNaoEncontrada
+		}
+		var republicada bool
+		if erro = repositorio.db.QueryRow(
+			"select count(*) > 0 from republicacoes where usuario_id = ? and publicacao_id = ?", usuarioID, publicacaoID,
+		).Scan(&republicada); erro != nil {
+			return false, erro
+		}
+		if !republicada {
+			return false, ErroPublicacaoNaoRepublicavel
+		}
+		return false, nil
+	}
+	if _, erro = repositorio.db.Exec("update publicacoes set republicacoes = republicacoes + 1 where id = ?", publicacaoID); erro != nil {
+		return false, erro
+	}
+	invalidarCache(repositorio.db, chavePublicacao(publicacaoID))
+	invalidarFeeds(repositorio.db)
+	return true, nil
+}
+
+// DesfazerRepublicacao tira a republicação de usuarioID e decrementa o contador da publicação. Se ele não tinha
+// republicado nada muda, o bool retornado diz se havia republicação. São dois comandos, então deve ser chamado dentro de uma transação
+func (repositorio Publicacoes) DesfazerRepublicacao(publicacaoID, usuarioID uint64) (bool, error) {
+	statement, erro := repositorio.db.Prepare("delete from republicacoes where usuario_id = ? and publicacao_id = ?")
+	if erro != nil {
+		return false, erro
+	}
+	defer statement.Close()
+	resultado, erro := statement.Exec(usuarioID, publicacaoID)
+	if erro != nil {
+		return false, erro
+	}
+	if linhasAfetadas, erro := resultado.RowsAffected(); erro != nil || linhasAfetadas == 0 {
+		return false, erro
+	}
+	if _, erro = repositorio.db.Exec("update publicacoes set republicacoes = CASE WHEN republicacoes > 0 THEN republicacoes - 1 ELSE republicacoes END where id = ?", publicacaoID); erro != nil {
+		return false, erro
+	}
+	invalidarCache(repositorio.db, chavePublicacao(publicacaoID))
+	invalidarFeeds(repositorio.db)
+	return true, nil
+}
+
+// BuscarCurtidas traz uma página dos usuários que curtiram a publicação, da curtida mais recente para a mais antiga,
+// sem os que têm bloqueio com usuarioLogadoID
+func (repositorio Publicacoes) BuscarCurtidas(publicacaoID, usuarioLogadoID uint64, pagina, limite int) ([]modelos.Usuario, error) {
+	linhas, erro := repositorio.db.Query(
+		"select u.id, u.nome, u.nick from curtidas c inner join usuarios u on u.id = c.usuario_id where c.publicacao_id = ? and "+semBloqueio("u.id")+
+			" order by c.criadoEm desc, u.id desc limit ? offset ?",
+		publicacaoID, usuarioLogadoID, usuarioLogadoID, limite, (pagina-1)*limite)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var usuarios []modelos.Usuario
+	for linhas.Next() {
+		var usuario modelos.Usuario
+		if erro = linhas.Scan(&usuario.ID, &usuario.Nome, &usuario.Nick); erro != nil {
+			return nil, erro
+		}
+		usuarios = append(usuarios, usuario)
+	}
+	return usuarios, nil
+}
+
+// completar coloca os anexos nas publicações lidas do banco e depois os dados de quem está vendo
+func (repositorio Publicacoes) completar(publicacoes []modelos.Publicacao, usuarioLogadoID uint64) error {
+	if erro := (Anexos{repositorio.db}).preencherPublicacoes(publicacoes); erro != nil {
 		return erro
 	}
-	return nil
+	return repositorio.preencherDadosDoLeitor(publicacoes, usuarioLogadoID)
 }
 
-// Descurtir incrementa o número de curtidas de uma publicação
-func (repositorio Publicacoes) Descurtir(publicacaoID uint64) error {
-	//criando declaração de atualização e a executando
-	statement, erro := repositorio.db.Prepare("update publicacoes set curtidas = CASE WHEN curtidas > 0 THEN curtidas - 1 ELSE curtidas END where id = ?")
+// preencherDadosDoLeitor completa as publicações com o que depende de quem está vendo, como curtidaPorMim.
+// Fica fora do cache, que guarda as publicações iguais para todo mundo
+func (repositorio Publicacoes) preencherDadosDoLeitor(publicacoes []modelos.Publicacao, usuarioLogadoID uint64) error {
+	if len(publicacoes) == 0 || usuarioLogadoID == 0 {
+		return nil
+	}
+	curtidas, erro := repositorio.marcadasPor("curtidas", publicacoes, usuarioLogadoID)
 	if erro != nil {
 		return erro
 	}
-	defer statement.Close()
-	if _, erro = statement.Exec(publicacaoID); erro != nil {
+	republicadas, erro := repositorio.marcadasPor("republicacoes", publicacoes, usuarioLogadoID)
+	if erro != nil {
 		return erro
 	}
+	for i := range publicacoes {
+		publicacoes[i].CurtidaPorMim = curtidas[publicacoes[i].ID]
+		publicacoes[i].RepublicadaPorMim = republicadas[publicacoes[i].ID]
+	}
 	return nil
 }
+
+// marcadasPor diz quais das publicações têm uma linha de usuarioID em tabela (curtidas ou republicacoes)
+func (repositorio Publicacoes) marcadasPor(tabela string, publicacoes []modelos.Publicacao, usuarioID uint64) (map[uint64]bool, error) {
+	argumentos := []interface{}{usuarioID}
+	for _, publicacao := range publicacoes {
+		argumentos = append(argumentos, publicacao.ID)
+	}
+	linhas, erro := repositorio.db.Query(
+		"select publicacao_id from "+tabela+" where usuario_id = ? and publicacao_id in ("+marcadores(len(publicacoes))+")", argumentos...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	marcadas := map[uint64]bool{}
+	for linhas.Next() {
+		var publicacaoID uint64
+		if erro = linhas.Scan(&publicacaoID); erro != nil {
+			return nil, erro
+		}
+		marcadas[publicacaoID] = true
+	}
+	return marcadas, nil
+}
+
+// criarRevisao guarda o título e conteúdo atuais da publicação no histórico
+func (repositorio Publicacoes) criarRevisao(publicacaoID uint64, publicacao modelos.Publicacao) error {
+	statement, erro := repositorio.db.Prepare(
+		"insert into revisoes_publicacoes (publicacao_id, titulo, conteudo) values (?,?,?)")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+	_, erro = statement.Exec(publicacaoID, publicacao.Titulo, publicacao.Conteudo)
+	return erro
+}
+
+// escanearPublicacao lê uma linha com as colunas de colunasDePublicacao
+func escanearPublicacao(linhas *sql.Rows) (modelos.Publicacao, error) {
+	var publicacao modelos.Publicacao
+	erro := linhas.Scan(destinosDePublicacao(&publicacao)...)
+	return publicacao, erro
+}
+
+// destinosDePublicacao são os campos onde as colunas de colunasDePublicacao são lidas, para queries que trazem colunas a mais
+func destinosDePublicacao(publicacao *modelos.Publicacao) []interface{} {
+	return []interface{}{
+		&publicacao.ID,
+		&publicacao.Titulo,
+		&publicacao.Conteudo,
+		&publicacao.AutorID,
+		&publicacao.Curtidas,
+		&publicacao.Comentarios,
+		&publicacao.Republicacoes,
+		&publicacao.Citacoes,
+		&publicacao.CitadaID,
+		&publicacao.Visibilidade,
+		&publicacao.AudienciaID,
+		&publicacao.CriadoEm,
+		&publicacao.Versao,
+		&publicacao.EditadoEm,
+		entidadesJSON{&publicacao.Entidades},
+		&publicacao.AutorNick,
+	}
+}
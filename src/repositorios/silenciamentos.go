@@ -0,0 +1,150 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"database/sql"
+	"time"
+)
+
+// silenciamentoValendo é o filtro dos silenciamentos que ainda não acabaram
+const silenciamentoValendo = "(ate is null or ate > now())"
+
+// Silenciar faz usuarioID silenciar silenciadoID até o momento em conta.Ate, ou até desfazer se não houver.
+// Silenciar de novo só troca o fim do silenciamento
+func (repositorio Usuarios) Silenciar(usuarioID, silenciadoID uint64, conta modelos.ContaSilenciada) error {
+	statement, erro := repositorio.db.Prepare(
+		"insert into silenciados (usuario_id, silenciado_id, ate) values (?, ?, ?) on duplicate key update ate = values(ate)")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+	_, erro = statement.Exec(usuarioID, silenciadoID, conta.Ate)
+	return erro
+}
+
+// DesfazerSilenciamento volta a mostrar silenciadoID para usuarioID
+func (repositorio Usuarios) DesfazerSilenciamento(usuarioID, silenciadoID uint64) error {
+	_, erro := repositorio.db.Exec("delete from silenciados where usuario_id = ? and silenciado_id = ?", usuarioID, silenciadoID)
+	return erro
+}
+
+// BuscarSilenciados traz as contas que usuarioID silenciou e ainda estão silenciadas, da mais recente para a mais antiga
+func (repositorio Usuarios) BuscarSilenciados(usuarioID uint64) ([]modelos.ContaSilenciada, error) {
+	linhas, erro := repositorio.db.Query(
+		"select u.id, u.nick, s.ate, s.criadoEm from silenciados s inner join usuarios u on u.id = s.silenciado_id "+
+			"where s.usuario_id = ? and "+silenciamentoValendo+" order by s.criadoEm desc", usuarioID)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var contas []modelos.ContaSilenciada
+	for linhas.Next() {
+		var conta modelos.ContaSilenciada
+		var ate sql.NullTime
+		if erro = linhas.Scan(&conta.UsuarioID, &conta.Nick, &ate, &conta.CriadoEm); erro != nil {
+			return nil, erro
+		}
+		if ate.Valid {
+			conta.Ate = &ate.Time
+		}
+		contas = append(contas, conta)
+	}
+	return contas, nil
+}
+
+// SilenciarPalavra faz usuarioID silenciar uma palavra ou frase. Silenciar de novo a mesma só troca o fim do silenciamento.
+// Retorna o id da palavra silenciada
+func (repositorio Usuarios) SilenciarPalavra(usuarioID uint64, palavra modelos.PalavraSilenciada) (uint64, error) {
+	statement, erro := repositorio.db.Prepare(
+		"insert into palavras_silenciadas (usuario_id, palavra, ate) values (?, ?, ?) " +
+			"on duplicate key update ate = values(ate), id = last_insert_id(id)")
+	if erro != nil {
+		return 0, erro
+	}
+	defer statement.Close()
+	resultado, erro := statement.Exec(usuarioID, palavra.Palavra, palavra.Ate)
+	if erro != nil {
+		return 0, erro
+	}
+	palavraID, erro := resultado.LastInsertId()
+	if erro != nil {
+		return 0, erro
+	}
+	return uint64(palavraID), nil
+}
+
+// DesfazerSilenciamentoDePalavra apaga uma palavra silenciada de usuarioID. O bool retornado diz se ela existia
+func (repositorio Usuarios) DesfazerSilenciamentoDePalavra(usuarioID, palavraID uint64) (bool, error) {
+	resultado, erro := repositorio.db.Exec("delete from palavras_silenciadas where id = ? and usuario_id = ?", palavraID, usuarioID)
+	if erro != nil {
+		return false, erro
+	}
+	linhasAfetadas, erro := resultado.RowsAffected()
+	return linhasAfetadas > 0, erro
+}
+
+// BuscarPalavrasSilenciadas traz as palavras que usuarioID silenciou e ainda estão silenciadas, da mais recente para a mais antiga
+func (repositorio Usuarios) BuscarPalavrasSilenciadas(usuarioID uint64) ([]modelos.PalavraSilenciada, error) {
+	linhas, erro := repositorio.db.Query(
+		"select id, palavra, ate, criadoEm from palavras_silenciadas where usuario_id = ? and "+silenciamentoValendo+" order by criadoEm desc, id desc", usuarioID)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var palavras []modelos.PalavraSilenciada
+	for linhas.Next() {
+		var palavra modelos.PalavraSilenciada
+		var ate sql.NullTime
+		if erro = linhas.Scan(&palavra.ID, &palavra.Palavra, &ate, &palavra.CriadoEm); erro != nil {
+			return nil, erro
+		}
+		if ate.Valid {
+			palavra.Ate = &ate.Time
+		}
+		palavras = append(palavras, palavra)
+	}
+	return palavras, nil
+}
+
+// buscarSilenciamentos junta as contas e palavras que usuarioID silenciou e ainda estão valendo
+func (repositorio Usuarios) buscarSilenciamentos(usuarioID uint64) (modelos.Silenciamentos, error) {
+	silenciamentos := modelos.Silenciamentos{Contas: map[uint64]bool{}}
+	linhas, erro := repositorio.db.Query(
+		"select silenciado_id from silenciados where usuario_id = ? and "+silenciamentoValendo, usuarioID)
+	if erro != nil {
+		return silenciamentos, erro
+	}
+	defer linhas.Close()
+	for linhas.Next() {
+		var silenciadoID uint64
+		if erro = linhas.Scan(&silenciadoID); erro != nil {
+			return silenciamentos, erro
+		}
+		silenciamentos.Contas[silenciadoID] = true
+	}
+	palavras, erro := repositorio.BuscarPalavrasSilenciadas(usuarioID)
+	if erro != nil {
+		return silenciamentos, erro
+	}
+	for _, palavra := range palavras {
+		silenciamentos.Palavras = append(silenciamentos.Palavras, palavra.Palavra)
+	}
+	return silenciamentos, nil
+}
+
+// PurgarSilenciamentosAcabados apaga os silenciamentos de contas e palavras que acabaram antes de limite
+func (repositorio Usuarios) PurgarSilenciamentosAcabados(limite time.Time) (int64, error) {
+	var apagados int64
+	for _, tabela := range []string{"silenciados", "palavras_silenciadas"} {
+		resultado, erro := repositorio.db.Exec("delete from "+tabela+" where ate is not null and ate < ?", limite)
+		if erro != nil {
+			return apagados, erro
+		}
+		linhasAfetadas, erro := resultado.RowsAffected()
+		if erro != nil {
+			return apagados, erro
+		}
+		apagados += linhasAfetadas
+	}
+	return apagados, nil
+}
@@ -0,0 +1,182 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"fmt"
+	"time"
+)
+
+const (
+	//sugestoesGuardadas é quantas sugestões de amigos de amigos cada usuário tem guardadas por vez
+	sugestoesGuardadas = 200
+	//janelaDeContasAtivas é há quanto tempo uma conta precisa ter publicado para entrar nas sugestões de contas populares
+	janelaDeContasAtivas = 30 * 24 * time.Hour
+	//seguidoresDesatualizadosPorMudanca é quantos seguidores de quem seguiu ou deixou de seguir alguém têm as sugestões
+	//refeitas, já que os amigos de amigos deles mudaram. Os que passarem disso só têm as sugestões refeitas quando eles
+	//mesmos seguirem ou deixarem de seguir alguém, é o quanto as sugestões de quem segue contas muito seguidas podem ficar velhas
+	seguidoresDesatualizadosPorMudanca = 1000
+)
+
+// podeSerSugerido é a condição da conta em coluna poder ser sugerida para o usuário dos cinco ?: não é ele mesmo,
+// ele ainda não segue nem pediu para seguir, não silenciou nem dispensou a sugestão. Bloqueios ficam com semBloqueio
+func podeSerSugerido(coluna string) string {
+	return fmt.Sprintf("%[1]s <> ? "+
+		"and not exists (select 1 from seguidores j where j.seguidor_id = ? and j.usuario_id = %[1]s) "+
+		"and not exists (select 1 from pedidos_para_seguir ps where ps.seguidor_id = ? and ps.usuario_id = %[1]s) "+
+		"and not exists (select 1 from silenciados si where si.usuario_id = ? and si.silenciado_id = %[1]s and "+silenciamentoValendo+") "+
+		"and not exists (select 1 from sugestoes_dispensadas d where d.usuario_id = ? and d.dispensado_id = %[1]s)", coluna)
+}
+
+// CalcularSugestoesParaSeguir refaz as sugestões guardadas de usuarioID com as contas seguidas por quem ele segue,
+// pontuadas por quantos dos que ele segue as seguem. São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Usuarios) CalcularSugestoesParaSeguir(usuarioID uint64) error {
+	//travando a linha do usuário, quem seguir alguém enquanto isso espera e marca as sugestões de novo depois do commit
+	if _, erro := repositorio.db.Exec("select id from usuarios where id = ? for update", usuarioID); erro != nil {
+		return erro
+	}
+	if _, erro := repositorio.db.Exec("delete from sugestoes_para_seguir where usuario_id = ?", usuarioID); erro != nil {
+		return erro
+	}
+	if _, erro := repositorio.db.Exec(
+		"insert into sugestoes_para_seguir (usuario_id, sugerido_id, pontos) "+
+			"select ?, s2.usuario_id, count(*) from seguidores s1 inner join seguidores s2 on s2.seguidor_id = s1.usuario_id "+
+			"where s1.seguidor_id = ? and "+podeSerSugerido("s2.usuario_id")+" "+
+			"group by s2.usuario_id order by count(*) desc limit ?",
+		usuarioID, usuarioID, usuarioID, usuarioID, usuarioID, usuarioID, usuarioID, sugestoesGuardadas); erro != nil {
+		return erro
+	}
+	_, erro := repositorio.db.Exec("update usuarios set sugestoes_pendentes = false where id = ?", usuarioID)
+	return erro
+}
+
+// BuscarSugestoesPendentes traz até limite usuários marcados por desatualizarSugestoes, os únicos cujas sugestões são refeitas
+func (repositorio Usuarios) BuscarSugestoesPendentes(limite int) ([]uint64, error) {
+	return repositorio.buscarIDsPendentes("select id from usuarios where sugestoes_pendentes limit ?", limite)
+}
+
+// BuscarSugestoesDosSeguidoresPendentes traz até limite usuários que seguiram ou deixaram de seguir alguém
+// e ainda não tiveram as sugestões dos seguidores marcadas com DesatualizarSugestoesDosSeguidores
+func (repositorio Usuarios) BuscarSugestoesDosSeguidoresPendentes(limite int) ([]uint64, error) {
+	return repositorio.buscarIDsPendentes("select id from usuarios where sugestoes_dos_seguidores_pendentes limit ?", limite)
+}
+
+// buscarIDsPendentes executa uma das queries das sugestões pendentes e lê os ids
+func (repositorio Usuarios) buscarIDsPendentes(query string, limite int) ([]uint64, error) {
+	linhas, erro := repositorio.db.Query(query, limite)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var ids []uint64
+	for linhas.Next() {
+		var id uint64
+		if erro = linhas.Scan(&id); erro != nil {
+			return nil, erro
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// desatualizarSugestoes marca as sugestões de usuarioID para serem refeitas, que mudam quando ele segue ou deixa de seguir alguém,
+// e as dos seguidores dele, para quem essa conta virou ou deixou de ser amiga de amigo. As dos seguidores são marcadas
+// depois, por DesatualizarSugestoesDosSeguidores, para não travar as linhas de todos eles junto com quem seguiu
+func (repositorio Usuarios) desatualizarSugestoes(usuarioID uint64) error {
+	_, erro := repositorio.db.Exec(
+		"update usuarios set sugestoes_pendentes = true, sugestoes_dos_seguidores_pendentes = true where id = ?", usuarioID)
+	return erro
+}
+
+// DesatualizarSugestoesDosSeguidores marca para serem refeitas as sugestões de até seguidoresDesatualizadosPorMudanca
+// seguidores de usuarioID. São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Usuarios) DesatualizarSugestoesDosSeguidores(usuarioID uint64) error {
+	//travando a linha do usuário, se ele seguir alguém enquanto isso a marcação volta depois do commit
+	if _, erro := repositorio.db.Exec("select id from usuarios where id = ? for update", usuarioID); erro != nil {
+		return erro
+	}
+	if _, erro := repositorio.db.Exec(
+		"update usuarios u inner join (select seguidor_id from seguidores where usuario_id = ? limit ?) s on s.seguidor_id = u.id "+
+			"set u.sugestoes_pendentes = true where not u.sugestoes_pendentes",
+		usuarioID, seguidoresDesatualizadosPorMudanca); erro != nil {
+		return erro
+	}
+	_, erro := repositorio.db.Exec("update usuarios set sugestoes_dos_seguidores_pendentes = false where id = ?", usuarioID)
+	return erro
+}
+
+// BuscarSugestoesParaSeguir traz até limite contas para usuarioID seguir. Primeiro vêm as guardadas de amigos de amigos,
+// e se não bastarem as contas com mais seguidores que publicaram nos últimos 30 dias. Quem ele já segue, pediu para seguir,
+// silenciou, dispensou ou tem bloqueio com ele fica de fora, mesmo que as sugestões guardadas ainda não saibam disso
+func (repositorio Usuarios) BuscarSugestoesParaSeguir(usuarioID uint64, limite int) ([]modelos.Usuario, error) {
+	sugestoes, erro := repositorio.buscarSugestoes(
+		"select u.id, u.nome, u.nick, u.criadoem, "+colunasDoPerfil+", "+colunasDosContadores+" from sugestoes_para_seguir sp "+
+			"inner join usuarios u on u.id = sp.sugerido_id where sp.usuario_id = ? and "+podeSerSugerido("u.id")+" and "+semBloqueio("u.id")+" "+
+			"order by sp.pontos desc, u.seguidores desc, u.id limit ?",
+		usuarioID, usuarioID, usuarioID, usuarioID, usuarioID, usuarioID, usuarioID, usuarioID, limite)
+	if erro != nil {
+		return nil, erro
+	}
+	if len(sugestoes) < limite {
+		//para quem ainda não segue ninguém, ou segue gente que não segue ninguém, o resto vem das contas populares
+		var jaSugeridos []interface{}
+		for _, sugestao := range sugestoes {
+			jaSugeridos = append(jaSugeridos, sugestao.ID)
+		}
+		naoRepetir := ""
+		if len(jaSugeridos) > 0 {
+			naoRepetir = "u.id not in (" + marcadores(len(jaSugeridos)) + ") and "
+		}
+		populares, erro := repositorio.buscarSugestoes(
+			"select u.id, u.nome, u.nick, u.criadoem, "+colunasDoPerfil+", "+colunasDosContadores+" from usuarios u "+
+				"where "+naoRepetir+podeSerSugerido("u.id")+" and "+semBloqueio("u.id")+" "+
+				"and exists (select 1 from publicacoes p where p.autor_id = u.id and p.criadoEm > ? and p.deletadoEm is null) "+
+				"order by u.seguidores desc, u.id limit ?",
+			argumentos(jaSugeridos, usuarioID, usuarioID, usuarioID, usuarioID, usuarioID, usuarioID, usuarioID,
+				time.Now().Add(-janelaDeContasAtivas), limite-len(sugestoes))...)
+		if erro != nil {
+			return nil, erro
+		}
+		sugestoes = append(sugestoes, populares...)
+	}
+	return sugestoes, repositorio.PreencherRelacoes(sugestoes, usuarioID)
+}
+
+// buscarSugestoes executa uma das queries de BuscarSugestoesParaSeguir e lê os usuários
+func (repositorio Usuarios) buscarSugestoes(query string, args ...interface{}) ([]modelos.Usuario, error) {
+	linhas, erro := repositorio.db.Query(query, args...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var usuarios []modelos.Usuario
+	for linhas.Next() {
+		var usuario modelos.Usuario
+		if erro = linhas.Scan(
+			&usuario.ID,
+			&usuario.Nome,
+			&usuario.Nick,
+			&usuario.CriadoEm,
+			&usuario.Bio,
+			&usuario.Site,
+			&usuario.Localizacao,
+			&usuario.Pronomes,
+			&usuario.AvatarID,
+			&usuario.BannerID,
+			&usuario.Seguidores,
+			&usuario.Seguindo,
+			&usuario.Publicacoes,
+		); erro != nil {
+			return nil, erro
+		}
+		preencherImagensDoPerfil(&usuario)
+		usuarios = append(usuarios, usuario)
+	}
+	return usuarios, nil
+}
+
+// DispensarSugestao faz dispensadoID não ser mais sugerido para usuarioID
+func (repositorio Usuarios) DispensarSugestao(usuarioID, dispensadoID uint64) error {
+	_, erro := repositorio.db.Exec(
+		"insert ignore into sugestoes_dispensadas (usuario_id, dispensado_id) values (?, ?)", usuarioID, dispensadoID)
+	return erro
+}
@@ -0,0 +1,114 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// executor é o que os repositórios usam para falar com o banco, pode ser uma conexão (*sql.DB) ou uma transação (*sql.Tx)
+type executor interface {
+	Prepare(query string) (*sql.Stmt, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// transacaoDoBanco é a transação que os repositórios recebem dentro de ExecutarTransacao. Guarda os efeitos das escritas
+// fora do banco, como invalidar o cache e atualizar o índice de busca, para só rodarem depois do commit
+type transacaoDoBanco struct {
+	*sql.Tx
+	efeitos []func()
+}
+
+// emTransacao diz se db é uma transação aberta por ExecutarTransacao
+func emTransacao(db executor) bool {
+	_, ehTransacao := db.(*transacaoDoBanco)
+	return ehTransacao
+}
+
+// depoisDoCommit roda efeito quando a escrita feita por db estiver confirmada: na hora se db for uma conexão,
+// ou depois do commit se for uma transação, que ainda pode ser desfeita ou repetida
+func depoisDoCommit(db executor, efeito func()) {
+	if transacao, ehTransacao := db.(*transacaoDoBanco); ehTransacao {
+		transacao.efeitos = append(transacao.efeitos, efeito)
+		return
+	}
+	efeito()
+}
+
+// Transacao agrupa os repositórios ligados a uma mesma transação do banco
+type Transacao struct {
+	Usuarios     *Usuarios
+	Publicacoes  *Publicacoes
+	Comentarios  *Comentarios
+	Hashtags     *Hashtags
+	Notificacoes *Notificacoes
+	Conversas    *Conversas
+	Audiencias   *Audiencias
+}
+
+// tentativasDeTransacao é quantas vezes uma transação é executada antes de desistir por deadlock
+const tentativasDeTransacao = 3
+
+// ExecutarTransacao roda funcao dentro de uma transação, confirmando tudo se ela não retornar erro e desfazendo caso contrário.
+// Se o banco abortar a transação por deadlock ou erro de serialização ela é executada novamente do zero,
+// então funcao não deve ter efeitos fora do banco; os dos repositórios (cache, índice de busca e notificações)
+// esperam o commit
+func ExecutarTransacao(db *sql.DB, funcao func(transacao Transacao) error) error {
+	var erro error
+	for tentativa := 1; tentativa <= tentativasDeTransacao; tentativa++ {
+		if erro = executarTransacao(db, funcao); erro == nil || !deveRepetir(erro) {
+			return erro
+		}
+		//esperando um pouco mais a cada tentativa pra dar tempo da outra transação terminar
+		time.Sleep(time.Duration(tentativa*20) * time.Millisecond)
+	}
+	return erro
+}
+
+// executarTransacao faz uma única tentativa de rodar funcao dentro de uma transação
+func executarTransacao(db *sql.DB, funcao func(transacao Transacao) error) error {
+	tx, erro := db.Begin()
+	if erro != nil {
+		return erro
+	}
+	//as notificações e os efeitos fora do banco só acontecem se a transação for confirmada
+	var notificacoes []modelos.Notificacao
+	executorDaTransacao := &transacaoDoBanco{Tx: tx}
+	transacao := Transacao{
+		Usuarios:     &Usuarios{executorDaTransacao},
+		Publicacoes:  &Publicacoes{executorDaTransacao, &notificacoes},
+		Comentarios:  &Comentarios{executorDaTransacao},
+		Hashtags:     &Hashtags{executorDaTransacao},
+		Notificacoes: &Notificacoes{executorDaTransacao, &notificacoes},
+		Conversas:    &Conversas{executorDaTransacao},
+		Audiencias:   &Audiencias{executorDaTransacao},
+	}
+	if erro = funcao(transacao); erro != nil {
+		tx.Rollback()
+		return erro
+	}
+	if erro = tx.Commit(); erro != nil {
+		return erro
+	}
+	for _, efeito := range executorDaTransacao.efeitos {
+		efeito()
+	}
+	for _, notificacao := range notificacoes {
+		AoNotificar(notificacao)
+	}
+	return nil
+}
+
+// deveRepetir diz se o erro é de deadlock (1213), de espera por lock (1205) ou de serialização (SQLSTATE 40001)
+func deveRepetir(erro error) bool {
+	var erroMySQL *mysql.MySQLError
+	if !errors.As(erro, &erroMySQL) {
+		return false
+	}
+	return erroMySQL.Number == 1213 || erroMySQL.Number == 1205 || string(erroMySQL.SQLState[:]) == "40001"
+}
@@ -1,14 +1,16 @@
 package repositorios
 
 import (
+	"api/src/busca"
 	"api/src/modelos"
 	"database/sql"
-	"fmt"
+	"strings"
+	"unicode/utf8"
 )
 
 // Usuarios representa o repositório de usuários
 type Usuarios struct {
-	db *sql.DB
+	db executor
 }
 
 // NovoRepositorioDeUsuarios cria um repositorio de usuarios
@@ -23,12 +25,13 @@ func NovoRepositorioDeUsuarios(db *sql.DB) *Usuarios {
 func (repositorio Usuarios) Criar(usuario modelos.Usuario) (uint64, error) {
 	//criando declaração de inserção e a executando
 	statement, erro := repositorio.db.Prepare(
-		"insert into usuarios (nome,nick,email,senha) values (?,?,?,?)")
+		"insert into usuarios (nome,nick,email,email_publico,senha,nome_busca,nick_busca,bio,site,localizacao,pronomes) values (?,?,?,?,?,?,?,?,?,?,?)")
 	if erro != nil {
 		return 0, erro
 	}
 	defer statement.Close()
-	resultado, erro := statement.Exec(usuario.Nome, usuario.Nick, usuario.Email, usuario.Senha)
+	resultado, erro := statement.Exec(usuario.Nome, usuario.Nick, usuario.Email, usuario.EmailPublico, usuario.Senha,
+		busca.Normalizar(usuario.Nome), busca.Normalizar(usuario.Nick), usuario.Bio, usuario.Site, usuario.Localizacao, usuario.Pronomes)
 	if erro != nil {
 		return 0, erro
 	}
@@ -41,13 +44,55 @@ func (repositorio Usuarios) Criar(usuario modelos.Usuario) (uint64, error) {
 
 }
 
-// Buscar traz todos os usuários que atendem o filtro de nome ou nicks
-func (repositorio Usuarios) Buscar(nomeOUnick string) ([]modelos.Usuario, error) {
-	nomeOUnick = fmt.Sprintf("%%%s%%", nomeOUnick) // %nomeOUnick% pra usar o comando alike do sql
-	//pegando usuarios que tenham nome ou nick igual ou contendo nomeOUnick
+// tamanhoMinimoDoFulltext é o innodb_ft_min_token_size padrão, palavras menores não entram no índice FULLTEXT
+const tamanhoMinimoDoFulltext = 3
+
+// palavrasIgnoradasPeloFulltext são as stopwords padrão do InnoDB, que também ficam fora do índice FULLTEXT
+var palavrasIgnoradasPeloFulltext = map[string]bool{
+	"a": true, "about": true, "an": true, "are": true, "as": true, "at": true, "be": true, "by": true, "com": true,
+	"de": true, "en": true, "for": true, "from": true, "how": true, "i": true, "in": true, "is": true, "it": true,
+	"la": true, "of": true, "on": true, "or": true, "that": true, "the": true, "this": true, "to": true, "was": true,
+	"what": true, "when": true, "where": true, "who": true, "will": true, "with": true, "und": true, "www": true,
+}
+
+// Buscar traz os usuários cujo nick ou nome batem com nomeOUnick, sem diferenciar maiúsculas e acentos.
+// Nick igual vem primeiro, depois nick começando com o termo e por fim nome com todas as palavras começando com
+// os termos; dentro de cada grupo quem tem mais seguidores vem antes. Quem tem bloqueio com usuarioLogadoID não aparece
+func (repositorio Usuarios) Buscar(nomeOUnick string, usuarioLogadoID uint64, pagina, limite int) ([]modelos.Usuario, error) {
+	nick := busca.Normalizar(strings.TrimSpace(nomeOUnick))
+	//pro nome cada palavra vira um prefixo obrigatório no modo booleano do fulltext (+palavra*). As que o fulltext
+	//não indexa, curtas ou stopwords, são procuradas com like no começo de alguma palavra do nome
+	var palavras, condicoesDoNome []string
+	var argumentosDoNome []interface{}
+	for _, termo := range busca.Termos(nomeOUnick) {
+		if utf8.RuneCountInString(termo) < tamanhoMinimoDoFulltext || palavrasIgnoradasPeloFulltext[termo] {
+			condicoesDoNome = append(condicoesDoNome, "(nome_busca like ? or nome_busca like ?)")
+			argumentosDoNome = append(argumentosDoNome, escaparLike(termo)+"%", "% "+escaparLike(termo)+"%")
+			continue
+		}
+		palavras = append(palavras, "+"+termo+"*")
+	}
+	if len(palavras) > 0 {
+		condicoesDoNome = append(condicoesDoNome, "match(nome_busca) against (? in boolean mode)")
+		argumentosDoNome = append(argumentosDoNome, strings.Join(palavras, " "))
+	}
+	if len(condicoesDoNome) == 0 {
+		condicoesDoNome = append(condicoesDoNome, "false")
+	}
+	//cada parte do union usa um índice, no lugar do like '%x%' que varria a tabela toda
 	linhas, erro := repositorio.db.Query(
-		"select id, nome, nick, email, criadoem from usuarios where nome LIKE ? or nick LIKE ?",
-		nomeOUnick, nomeOUnick,
+		`select u.id, u.nome, u.nick, u.email, u.email_publico, u.criadoem, `+colunasDoPerfil+`, `+colunasDosContadores+` from usuarios u
+		inner join (
+			select id, max(grupo) grupo from (
+				select id, 3 grupo from usuarios where nick_busca = ?
+				union all select id, 2 grupo from usuarios where nick_busca like ?
+				union all select id, 1 grupo from usuarios where `+strings.Join(condicoesDoNome, " and ")+`
+			) encontrados group by id
+		) r on r.id = u.id
+		where `+semBloqueio("u.id")+`
+		order by r.grupo desc, u.seguidores desc, u.id
+		limit ? offset ?`,
+		argumentos(nick, escaparLike(nick)+"%", argumentosDoNome, usuarioLogadoID, usuarioLogadoID, limite, (pagina-1)*limite)...,
 	)
 	if erro != nil {
 		return nil, erro
@@ -62,60 +107,153 @@ func (repositorio Usuarios) Buscar(nomeOUnick string) ([]modelos.Usuario, error)
 			&usuario.Nome,
 			&usuario.Nick,
 			&usuario.Email,
+			&usuario.EmailPublico,
 			&usuario.CriadoEm,
+			&usuario.Bio,
+			&usuario.Site,
+			&usuario.Localizacao,
+			&usuario.Pronomes,
+			&usuario.AvatarID,
+			&usuario.BannerID,
+			&usuario.Seguidores,
+			&usuario.Seguindo,
+			&usuario.Publicacoes,
 		); erro != nil {
 			return nil, erro
 		}
+		preencherImagensDoPerfil(&usuario)
 		usuarios = append(usuarios, usuario)
 	}
 
+	return usuarios, repositorio.PreencherRelacoes(usuarios, usuarioLogadoID)
+}
+
+// Sugerir traz até limite usuários com nick ou nome começando com prefixo, para completar enquanto o usuário digita.
+// Só lê os índices (nick_busca, nick, nome) e (nome_busca, nick, nome), sem ir nas linhas da tabela.
+// Quem tem bloqueio com usuarioLogadoID não aparece
+func (repositorio Usuarios) Sugerir(prefixo string, usuarioLogadoID uint64, limite int) ([]modelos.Usuario, error) {
+	prefixo = escaparLike(busca.Normalizar(strings.TrimSpace(prefixo))) + "%"
+	linhas, erro := repositorio.db.Query(
+		`select id, nome, nick from (
+			(select id, nome, nick, nick_busca ordem, 1 grupo from usuarios use index (idx_usuarios_nick_busca) where nick_busca like ? order by nick_busca limit ?)
+			union
+			(select id, nome, nick, nome_busca ordem, 2 grupo from usuarios use index (idx_usuarios_nome_busca) where nome_busca like ? order by nome_busca limit ?)
+		) sugestoes where `+semBloqueio("id")+` group by id, nome, nick order by min(grupo), min(ordem) limit ?`,
+		prefixo, limite, prefixo, limite, usuarioLogadoID, usuarioLogadoID, limite,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var usuarios []modelos.Usuario
+	for linhas.Next() {
+		var usuario modelos.Usuario
+		if erro = linhas.Scan(&usuario.ID, &usuario.Nome, &usuario.Nick); erro != nil {
+			return nil, erro
+		}
+		usuarios = append(usuarios, usuario)
+	}
 	return usuarios, nil
 }
 
-// BuscarPorID traz os dados de um usuário por seu id
+// BuscarPorID traz os dados de um usuário por seu id, passando antes pelo cache
 func (repositorio Usuarios) BuscarPorID(ID uint64) (modelos.Usuario, error) {
+	var usuario modelos.Usuario
+	if buscarDoCache(repositorio.db, chaveUsuario(ID), &usuario) {
+		return usuario, nil
+	}
 	//selecionando usuario que tenha o id recebido
 	linha, erro := repositorio.db.Query(
-		"select id, nome, nick, email, criadoem from usuarios where id = ?", ID)
+		"select id, nome, nick, email, email_publico, criadoem, versao, privado, "+colunasDoPerfil+", "+colunasDosContadores+" from usuarios u where id = ?", ID)
 	if erro != nil {
 		return modelos.Usuario{}, erro
 	}
 	defer linha.Close()
 	//passando os dados do usuario para uma struct e a retornando
-	var usuario modelos.Usuario
 	if linha.Next() {
 		if erro = linha.Scan(
 			&usuario.ID,
 			&usuario.Nome,
 			&usuario.Nick,
 			&usuario.Email,
+			&usuario.EmailPublico,
 			&usuario.CriadoEm,
+			&usuario.Versao,
+			&usuario.Privado,
+			&usuario.Bio,
+			&usuario.Site,
+			&usuario.Localizacao,
+			&usuario.Pronomes,
+			&usuario.AvatarID,
+			&usuario.BannerID,
+			&usuario.Seguidores,
+			&usuario.Seguindo,
+			&usuario.Publicacoes,
 		); erro != nil {
 			return modelos.Usuario{}, erro
 		}
+		preencherImagensDoPerfil(&usuario)
+		salvarNoCache(repositorio.db, chaveUsuario(ID), usuario)
 	}
 
 	return usuario, nil
 }
 
-// Atualizar atualiza os dados de usuario exceto a senha
+// Atualizar atualiza os dados e o perfil de usuario exceto a senha. O perfil inteiro é trocado, campos vazios apagam o que havia.
+// Se usuario.Versao for diferente de 0 a atualização só acontece se a versão salva for a mesma, caso contrário retorna ErroVersaoDesatualizada
 func (repositorio Usuarios) Atualizar(ID uint64, usuario modelos.Usuario) error {
 	//criando declaração de atualização e a executando
 	statement, erro := repositorio.db.Prepare(
-		"update usuarios set nome = ?, nick = ?, email = ? where id = ?")
+		"update usuarios set nome = ?, nick = ?, email = ?, email_publico = ?, nome_busca = ?, nick_busca = ?, bio = ?, site = ?, localizacao = ?, pronomes = ?, " +
+			"avatar_id = ?, banner_id = ?, versao = versao + 1 where id = ? and (? = 0 or versao = ?)")
 	if erro != nil {
 		return erro
 	}
 	defer statement.Close()
-	_, erro = statement.Exec(usuario.Nome, usuario.Nick, usuario.Email, ID)
+	resultado, erro := statement.Exec(usuario.Nome, usuario.Nick, usuario.Email, usuario.EmailPublico,
+		busca.Normalizar(usuario.Nome), busca.Normalizar(usuario.Nick), usuario.Bio, usuario.Site, usuario.Localizacao, usuario.Pronomes,
+		usuario.AvatarID, usuario.BannerID, ID, usuario.Versao, usuario.Versao)
 	if erro != nil {
 		return erro
 	}
+	if erro = verificarVersao(resultado, usuario.Versao); erro != nil {
+		return erro
+	}
+	//o nick aparece nas publicações do feed, então os feeds também ficam velhos
+	invalidarCache(repositorio.db, chaveUsuario(ID))
+	invalidarFeeds(repositorio.db)
 	return nil
 }
 
-// Deletar deleta os dados de um usuário
+// colunasDoPerfil são as colunas do perfil lidas junto com o usuário, com o apelido u para usuarios
+const colunasDoPerfil = "u.bio, u.site, u.localizacao, u.pronomes, u.avatar_id, u.banner_id"
+
+// preencherImagensDoPerfil monta as urls do avatar e do banner a partir dos ids dos anexos
+func preencherImagensDoPerfil(usuario *modelos.Usuario) {
+	if usuario.AvatarID != nil {
+		usuario.AvatarURL = urlDoAnexo(*usuario.AvatarID)
+		usuario.AvatarMiniaturaURL = urlDaMiniatura(*usuario.AvatarID)
+	}
+	if usuario.BannerID != nil {
+		usuario.BannerURL = urlDoAnexo(*usuario.BannerID)
+	}
+}
+
+// Deletar deleta os dados de um usuário e o tira dos contadores de quem ele seguia e de quem o seguia e das
+// publicações em que ele curtiu, republicou, comentou ou citou. Os comentários dele viram comentários apagados sem autor.
+// São vários comandos, então deve ser chamado dentro de uma transação
 func (repositorio Usuarios) Deletar(ID uint64) error {
+	if erro := repositorio.descontarRelacoes(ID); erro != nil {
+		return erro
+	}
+	if erro := repositorio.descontarInteracoes(ID); erro != nil {
+		return erro
+	}
+	//os comentários dele ficam apagados como em Comentarios.Deletar, sem autor, para as respostas dos outros continuarem na árvore
+	if _, erro := repositorio.db.Exec(
+		"update comentarios set conteudo = '', deletadoEm = now() where autor_id = ? and deletadoEm is null", ID); erro != nil {
+		return erro
+	}
 	//criando declaração de deletar e a executando
 	statement, erro := repositorio.db.Prepare(
 		"delete from usuarios where id = ?")
@@ -127,9 +265,22 @@ func (repositorio Usuarios) Deletar(ID uint64) error {
 	if erro != nil {
 		return erro
 	}
+	//as publicações do usuário são apagadas junto (on delete cascade)
+	invalidarCache(repositorio.db, chaveUsuario(ID))
+	invalidarFeeds(repositorio.db)
 	return nil
 }
 
+// EhAdmin diz se o usuário é administrador. Não há rota para isso, o campo admin é marcado direto no banco
+func (repositorio Usuarios) EhAdmin(usuarioID uint64) (bool, error) {
+	var admin bool
+	erro := repositorio.db.QueryRow("select admin from usuarios where id = ?", usuarioID).Scan(&admin)
+	if erro == sql.ErrNoRows {
+		return false, nil
+	}
+	return admin, erro
+}
+
 // BuscarPorEmail busca o id e senha de um usuario do banco usando email
 func (repositorio Usuarios) BuscarPorEmail(email string) (modelos.Usuario, error) {
 	//selecionando usuario que tenha o email recebido
@@ -153,39 +304,117 @@ func (repositorio Usuarios) BuscarPorEmail(email string) (modelos.Usuario, error
 	return usuario, nil
 }
 
-// Seguir faz o usuário de id seguidorID seguir o usuário de id usuarioID
-func (repositorio Usuarios) Seguir(usuarioID, seguidorID uint64) error {
+// Seguir faz o usuário de id seguidorID seguir o usuário de id usuarioID, atualizando os contadores dos dois
+// e marcando as sugestões de quem seguir do seguidor e dos seguidores dele para serem refeitas.
+// Seguir de novo não muda nada, o bool retornado diz se ele passou a seguir agora.
+// São vários comandos, então deve ser chamado dentro de uma transação
+func (repositorio Usuarios) Seguir(usuarioID, seguidorID uint64) (bool, error) {
 	statement, erro := repositorio.db.Prepare("insert ignore into seguidores (usuario_id, seguidor_id) values (?,?)")
 	if erro != nil {
-		return erro
+		return false, erro
 	}
 	defer statement.Close()
-	_, erro = statement.Exec(usuarioID, seguidorID)
+	resultado, erro := statement.Exec(usuarioID, seguidorID)
 	if erro != nil {
-		return erro
+		return false, erro
 	}
-	return nil
+	linhasAfetadas, erro := resultado.RowsAffected()
+	if erro != nil {
+		return false, erro
+	}
+	if linhasAfetadas > 0 {
+		if erro = repositorio.ajustarContadoresDeSeguir(usuarioID, seguidorID, 1); erro != nil {
+			return false, erro
+		}
+		if erro = repositorio.desatualizarSugestoes(seguidorID); erro != nil {
+			return false, erro
+		}
+	}
+	invalidarCache(repositorio.db, chaveUsuario(usuarioID), chaveUsuario(seguidorID), chaveFeed(seguidorID))
+	return linhasAfetadas > 0, nil
 }
 
-// PararDeSeguir faz o usuário de id seguidorID parar de seguir o usuário de id usuarioID
+// PararDeSeguir faz o usuário de id seguidorID parar de seguir o usuário de id usuarioID,
+// cancelando também o pedido para seguir se ele ainda não tiver sido aprovado e atualizando os contadores e as sugestões.
+// São vários comandos, então deve ser chamado dentro de uma transação
 func (repositorio Usuarios) PararDeSeguir(usuarioID, seguidorID uint64) error {
+	if _, erro := repositorio.db.Exec(
+		"delete from pedidos_para_seguir where usuario_id = ? and seguidor_id = ?", usuarioID, seguidorID); erro != nil {
+		return erro
+	}
 	statement, erro := repositorio.db.Prepare("delete from seguidores where usuario_id=? and seguidor_id=?")
 	if erro != nil {
 		return erro
 	}
 	defer statement.Close()
-	_, erro = statement.Exec(usuarioID, seguidorID)
+	resultado, erro := statement.Exec(usuarioID, seguidorID)
 	if erro != nil {
 		return erro
 	}
+	linhasAfetadas, erro := resultado.RowsAffected()
+	if erro != nil {
+		return erro
+	}
+	if linhasAfetadas > 0 {
+		if erro = repositorio.ajustarContadoresDeSeguir(usuarioID, seguidorID, -1); erro != nil {
+			return erro
+		}
+		if erro = repositorio.desatualizarSugestoes(seguidorID); erro != nil {
+			return erro
+		}
+	}
+	invalidarCache(repositorio.db, chaveUsuario(usuarioID), chaveUsuario(seguidorID), chaveFeed(seguidorID))
 	return nil
 }
 
-// BuscarSeguidores busca todos seguidores de um usuario de id usuarioID
-func (repositorio Usuarios) BuscarSeguidores(usuarioID uint64) ([]modelos.Usuario, error) {
+// Segue diz se seguidorID segue usuarioID
+func (repositorio Usuarios) Segue(seguidorID, usuarioID uint64) (bool, error) {
+	var segue bool
+	erro := repositorio.db.QueryRow(
+		"select count(*) > 0 from seguidores where usuario_id = ? and seguidor_id = ?", usuarioID, seguidorID).Scan(&segue)
+	return segue, erro
+}
+
+// BuscarPermissaoDeMensagens traz quem pode mandar mensagens diretas para o usuário (todos, seguidos ou ninguem)
+func (repositorio Usuarios) BuscarPermissaoDeMensagens(usuarioID uint64) (string, error) {
+	var permissao string
+	erro := repositorio.db.QueryRow("select mensagensDe from usuarios where id = ?", usuarioID).Scan(&permissao)
+	if erro == sql.ErrNoRows {
+		return "", nil
+	}
+	return permissao, erro
+}
+
+// AtualizarPermissaoDeMensagens muda quem pode mandar mensagens diretas para o usuário
+func (repositorio Usuarios) AtualizarPermissaoDeMensagens(usuarioID uint64, permissao string) error {
+	_, erro := repositorio.db.Exec("update usuarios set mensagensDe = ? where id = ?", permissao, usuarioID)
+	return erro
+}
+
+// BuscarIDsDosSeguidores traz só os ids dos seguidores de usuarioID, para quando os dados deles não importam
+func (repositorio Usuarios) BuscarIDsDosSeguidores(usuarioID uint64) ([]uint64, error) {
+	linhas, erro := repositorio.db.Query("select seguidor_id from seguidores where usuario_id = ?", usuarioID)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var ids []uint64
+	for linhas.Next() {
+		var id uint64
+		if erro = linhas.Scan(&id); erro != nil {
+			return nil, erro
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// BuscarSeguidores busca todos seguidores de um usuario de id usuarioID, menos os que têm bloqueio com usuarioLogadoID
+func (repositorio Usuarios) BuscarSeguidores(usuarioID, usuarioLogadoID uint64) ([]modelos.Usuario, error) {
 	//selecionando linhas que tenha o usuarioID como seguido (campo usuario_id)
 	linhas, erro := repositorio.db.Query(
-		"select u.id, u.nome, u.nick, u.email, u.criadoem from usuarios u inner join seguidores s on u.id = s.seguidor_id where s.usuario_id=?", usuarioID)
+		"select u.id, u.nome, u.nick, u.email, u.email_publico, u.criadoem, "+colunasDosContadores+" from usuarios u inner join seguidores s on u.id = s.seguidor_id where s.usuario_id=? and "+semBloqueio("u.id"),
+		usuarioID, usuarioLogadoID, usuarioLogadoID)
 	if erro != nil {
 		return nil, erro
 	}
@@ -198,20 +427,25 @@ func (repositorio Usuarios) BuscarSeguidores(usuarioID uint64) ([]modelos.Usuari
 			&seguidor.Nome,
 			&seguidor.Nick,
 			&seguidor.Email,
+			&seguidor.EmailPublico,
 			&seguidor.CriadoEm,
+			&seguidor.Seguidores,
+			&seguidor.Seguindo,
+			&seguidor.Publicacoes,
 		); erro != nil {
 			return nil, erro
 		}
 		seguidores = append(seguidores, seguidor)
 	}
-	return seguidores, nil
+	return seguidores, repositorio.PreencherRelacoes(seguidores, usuarioLogadoID)
 }
 
-// BuscarSeguindo traz todos usuários que um usuário de id usuarioID está seguindo
-func (repositorio Usuarios) BuscarSeguindo(usuarioID uint64) ([]modelos.Usuario, error) {
+// BuscarSeguindo traz todos usuários que um usuário de id usuarioID está seguindo, menos os que têm bloqueio com usuarioLogadoID
+func (repositorio Usuarios) BuscarSeguindo(usuarioID, usuarioLogadoID uint64) ([]modelos.Usuario, error) {
 	//selecionando linhas que tenha o usuarioID como seguidor (campo seguidor_id)
 	linhas, erro := repositorio.db.Query(
-		"select u.id, u.nome, u.nick, u.email, u.criadoem from usuarios u inner join seguidores s on u.id = s.usuario_id where s.seguidor_id=?", usuarioID)
+		"select u.id, u.nome, u.nick, u.email, u.email_publico, u.criadoem, "+colunasDosContadores+" from usuarios u inner join seguidores s on u.id = s.usuario_id where s.seguidor_id=? and "+semBloqueio("u.id"),
+		usuarioID, usuarioLogadoID, usuarioLogadoID)
 	if erro != nil {
 		return nil, erro
 	}
@@ -224,13 +458,17 @@ func (repositorio Usuarios) BuscarSeguindo(usuarioID uint64) ([]modelos.Usuario,
 			&seguido.Nome,
 			&seguido.Nick,
 			&seguido.Email,
+			&seguido.EmailPublico,
 			&seguido.CriadoEm,
+			&seguido.Seguidores,
+			&seguido.Seguindo,
+			&seguido.Publicacoes,
 		); erro != nil {
 			return nil, erro
 		}
 		seguindo = append(seguindo, seguido)
 	}
-	return seguindo, nil
+	return seguindo, repositorio.PreencherRelacoes(seguindo, usuarioLogadoID)
 }
 
 // BuscarSenha busca a senha de um usuario do banco usando id
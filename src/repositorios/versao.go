@@ -0,0 +1,25 @@
+package repositorios
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ErroVersaoDesatualizada é retornado quando a versão enviada numa atualização não é mais a que está salva no banco
+var ErroVersaoDesatualizada = errors.New("o registro foi alterado por outra requisição, busque a versão atual e tente novamente")
+
+// verificarVersao confere se uma atualização condicionada a versão realmente alterou alguma linha
+func verificarVersao(resultado sql.Result, versao uint64) error {
+	//versao 0 é uma escrita incondicional, não tem o que verificar
+	if versao == 0 {
+		return nil
+	}
+	linhasAfetadas, erro := resultado.RowsAffected()
+	if erro != nil {
+		return erro
+	}
+	if linhasAfetadas == 0 {
+		return ErroVersaoDesatualizada
+	}
+	return nil
+}
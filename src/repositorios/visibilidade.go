@@ -0,0 +1,93 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"fmt"
+)
+
+// publicacaoVisivel é o filtro das publicações, com apelido p na consulta, que quem está vendo pode ver: nem o
+// autor nem ele bloquearam o outro, a conta do autor é visível para ele e a visibilidade da publicação o inclui.
+// O autor sempre vê as próprias publicações. Os argumentos vêm de visibilidadePara
+func publicacaoVisivel(p string) string {
+	return semBloqueio(p+".autor_id") + " and " + contaVisivel(p+".autor_id") + fmt.Sprintf(
+		" and (%[1]s.autor_id = ? or %[1]s.visibilidade = '%[2]s'"+
+			" or (%[1]s.visibilidade = '%[3]s' and exists (select 1 from seguidores sp where sp.usuario_id = %[1]s.autor_id and sp.seguidor_id = ?))"+
+			" or (%[1]s.visibilidade = '%[4]s' and exists (select 1 from membros_audiencias ma where ma.audiencia_id = %[1]s.audiencia_id and ma.usuario_id = ?)))",
+		p, modelos.VisibilidadePublica, modelos.VisibilidadeSeguidores, modelos.VisibilidadeAudiencia)
+}
+
+// visibilidadePara são os argumentos de publicacaoVisivel para quem está vendo
+func visibilidadePara(usuarioLogadoID uint64) []interface{} {
+	return []interface{}{
+		usuarioLogadoID, usuarioLogadoID, usuarioLogadoID, usuarioLogadoID,
+		usuarioLogadoID, usuarioLogadoID, usuarioLogadoID,
+	}
+}
+
+// argumentos junta os argumentos de uma consulta, abrindo os que vierem em listas
+func argumentos(valores ...interface{}) []interface{} {
+	var juntos []interface{}
+	for _, valor := range valores {
+		if lista, ehLista := valor.([]interface{}); ehLista {
+			juntos = append(juntos, lista...)
+			continue
+		}
+		juntos = append(juntos, valor)
+	}
+	return juntos
+}
+
+// visivel diz se a publicação existe, não foi deletada e usuarioLogadoID pode vê-la, para quando ela veio do cache
+// e não passou pelo filtro ou quando um insert com select não achou nada
+func (repositorio Publicacoes) visivel(publicacaoID, usuarioLogadoID uint64) (bool, error) {
+	var visivel bool
+	erro := repositorio.db.QueryRow(
+		"select count(*) > 0 from publicacoes p where p.id = ? and p.deletadoEm is null and "+publicacaoVisivel("p"),
+		argumentos(publicacaoID, visibilidadePara(usuarioLogadoID))...).Scan(&visivel)
+	return visivel, erro
+}
+
+// filtrarVisiveis diz quais das publicações existem, não foram deletadas e usuarioLogadoID pode ver
+func (repositorio Publicacoes) filtrarVisiveis(publicacoesIDs []interface{}, usuarioLogadoID uint64) (map[uint64]bool, error) {
+	visiveis := map[uint64]bool{}
+	if len(publicacoesIDs) == 0 {
+		return visiveis, nil
+	}
+	linhas, erro := repositorio.db.Query(
+		"select p.id from publicacoes p where p.id in ("+marcadores(len(publicacoesIDs))+") and p.deletadoEm is null and "+publicacaoVisivel("p"),
+		argumentos(publicacoesIDs, visibilidadePara(usuarioLogadoID))...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	for linhas.Next() {
+		var publicacaoID uint64
+		if erro = linhas.Scan(&publicacaoID); erro != nil {
+			return nil, erro
+		}
+		visiveis[publicacaoID] = true
+	}
+	return visiveis, nil
+}
+
+// BuscarIDsDosLeitores traz os ids dos seguidores do autor que podem ver a publicação, para entregá-la em tempo real
+func (repositorio Publicacoes) BuscarIDsDosLeitores(publicacaoID uint64) ([]uint64, error) {
+	linhas, erro := repositorio.db.Query(
+		"select s.seguidor_id from publicacoes p inner join seguidores s on s.usuario_id = p.autor_id where p.id = ? and "+
+			"(p.visibilidade in (?, ?) or (p.visibilidade = ? and exists "+
+			"(select 1 from membros_audiencias ma where ma.audiencia_id = p.audiencia_id and ma.usuario_id = s.seguidor_id)))",
+		publicacaoID, modelos.VisibilidadePublica, modelos.VisibilidadeSeguidores, modelos.VisibilidadeAudiencia)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+	var ids []uint64
+	for linhas.Next() {
+		var id uint64
+		if erro = linhas.Scan(&id); erro != nil {
+			return nil, erro
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
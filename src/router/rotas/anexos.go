@@ -0,0 +1,33 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotasAnexos = []Rota{
+	{
+		URI:                "/anexos",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.CriarAnexo,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/anexos/{anexoId}",
+		Metodo:             http.MethodPut,
+		Funcao:             controllers.AtualizarAnexo,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/anexos/{anexoId}/arquivo",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarArquivoDoAnexo,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/anexos/{anexoId}/miniatura",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarMiniaturaDoAnexo,
+		RequerAutenticacao: true,
+	},
+}
@@ -0,0 +1,39 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotasAudiencias = []Rota{
+	{
+		URI:                "/audiencias",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.CriarAudiencia,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/audiencias",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarAudiencias,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/audiencias/{audienciaId}",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarAudiencia,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/audiencias/{audienciaId}",
+		Metodo:             http.MethodPut,
+		Funcao:             controllers.AtualizarAudiencia,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/audiencias/{audienciaId}",
+		Metodo:             http.MethodDelete,
+		Funcao:             controllers.DeletarAudiencia,
+		RequerAutenticacao: true,
+	},
+}
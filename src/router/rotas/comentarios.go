@@ -0,0 +1,39 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotasComentarios = []Rota{
+	{
+		URI:                "/publicacoes/{publicacaoId}/comentarios",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.CriarComentario,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/publicacoes/{publicacaoId}/comentarios",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarComentarios,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/comentarios/{comentarioId}/respostas",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarRespostasDoComentario,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/comentarios/{comentarioId}",
+		Metodo:             http.MethodPut,
+		Funcao:             controllers.AtualizarComentario,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/comentarios/{comentarioId}",
+		Metodo:             http.MethodDelete,
+		Funcao:             controllers.DeletarComentario,
+		RequerAutenticacao: true,
+	},
+}
@@ -0,0 +1,51 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotasConversas = []Rota{
+	{
+		URI:                "/conversas",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.CriarConversa,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/conversas",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarConversas,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/conversas/{conversaId}/mensagens",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarMensagensDaConversa,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/conversas/{conversaId}/mensagens",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.EnviarMensagem,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/conversas/{conversaId}/ler",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.MarcarConversaComoLida,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/mensagens/{mensagemId}",
+		Metodo:             http.MethodDelete,
+		Funcao:             controllers.DeletarMensagem,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/{usuarioId}/permissao-de-mensagens",
+		Metodo:             http.MethodPut,
+		Funcao:             controllers.AtualizarPermissaoDeMensagens,
+		RequerAutenticacao: true,
+	},
+}
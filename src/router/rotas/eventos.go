@@ -0,0 +1,14 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotaEventos = Rota{
+	URI:                    "/eventos",
+	Metodo:                 http.MethodGet,
+	Funcao:                 controllers.AcompanharEventos,
+	RequerAutenticacao:     true,
+	AceitaTokenDoNavegador: true,
+}
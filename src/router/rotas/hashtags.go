@@ -0,0 +1,21 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotasHashtags = []Rota{
+	{
+		URI:                "/hashtags/em-alta",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarHashtagsEmAlta,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/hashtags/{tag}/publicacoes",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarPublicacoesPorHashtag,
+		RequerAutenticacao: true,
+	},
+}
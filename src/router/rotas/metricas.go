@@ -0,0 +1,13 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotaMetricasDoCache = Rota{
+	URI:                "/metricas/cache",
+	Metodo:             http.MethodGet,
+	Funcao:             controllers.BuscarMetricasDoCache,
+	RequerAutenticacao: true,
+}
@@ -0,0 +1,27 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotasNotificacoes = []Rota{
+	{
+		URI:                "/notificacoes",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarNotificacoes,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/notificacoes/ler-todas",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.MarcarTodasNotificacoesComoLidas,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/notificacoes/{notificacaoId}/ler",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.MarcarNotificacaoComoLida,
+		RequerAutenticacao: true,
+	},
+}
@@ -18,6 +18,13 @@ var rotasPublicacoes = []Rota{
 		Funcao:             controllers.BuscarPublicacoes,
 		RequerAutenticacao: true,
 	},
+	//precisa vir antes de /publicacoes/{publicacaoId} para "busca" não ser lido como um id
+	{
+		URI:                "/publicacoes/busca",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.PesquisarPublicacoes,
+		RequerAutenticacao: true,
+	},
 	{
 		URI:                "/publicacoes/{publicacaoId}",
 		Metodo:             http.MethodGet,
@@ -42,6 +49,24 @@ var rotasPublicacoes = []Rota{
 		Funcao:             controllers.BuscarPublicacoesPorUsuario,
 		RequerAutenticacao: true,
 	},
+	{
+		URI:                "/usuarios/{usuarioId}/mencoes",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarMencoesDoUsuario,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/publicacoes/{publicacaoId}/historico",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarHistoricoDaPublicacao,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/publicacoes/{publicacaoId}/curtidas",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarCurtidasDaPublicacao,
+		RequerAutenticacao: true,
+	},
 	{
 		URI:                "/publicacoes/{publicacaoId}/curtir",
 		Metodo:             http.MethodPost,
@@ -54,4 +79,16 @@ var rotasPublicacoes = []Rota{
 		Funcao:             controllers.DescurtirPublicacao,
 		RequerAutenticacao: true,
 	},
+	{
+		URI:                "/publicacoes/{publicacaoId}/republicar",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.RepublicarPublicacao,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/publicacoes/{publicacaoId}/desfazer-republicacao",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.DesfazerRepublicacao,
+		RequerAutenticacao: true,
+	},
 }
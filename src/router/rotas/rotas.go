@@ -13,19 +13,34 @@ type Rota struct {
 	Metodo             string
 	Funcao             func(http.ResponseWriter, *http.Request)
 	RequerAutenticacao bool
+	//AceitaTokenDoNavegador deixa o token vir na url ou no Sec-WebSocket-Protocol, para o EventSource e o WebSocket do navegador
+	AceitaTokenDoNavegador bool
 }
 
 // Configurar coloca as rotas dentro do router, dependendo se estão autenticadas
 func Configurar(r *mux.Router) *mux.Router {
 	rotas := rotasUsuarios
 	rotas = append(rotas, rotaLogin)
+	rotas = append(rotas, rotaMetricasDoCache)
 	rotas = append(rotas, rotasPublicacoes...) //... faz o append de todas as rotas de dentro do slice
+	rotas = append(rotas, rotasComentarios...)
+	rotas = append(rotas, rotasHashtags...)
+	rotas = append(rotas, rotasNotificacoes...)
+	rotas = append(rotas, rotasConversas...)
+	rotas = append(rotas, rotasPalavrasSilenciadas...)
+	rotas = append(rotas, rotasAudiencias...)
+	rotas = append(rotas, rotasAnexos...)
+	rotas = append(rotas, rotaEventos)
 	for _, rota := range rotas {
+		funcao := rota.Funcao
 		if rota.RequerAutenticacao {
-			r.HandleFunc(rota.URI, middlewares.Logger(middlewares.Autenticar(rota.Funcao))).Methods(rota.Metodo)
-		} else {
-			r.HandleFunc(rota.URI, middlewares.Logger(rota.Funcao)).Methods(rota.Metodo)
+			funcao = middlewares.Autenticar(funcao)
 		}
+		funcao = middlewares.Logger(funcao)
+		if rota.AceitaTokenDoNavegador {
+			funcao = middlewares.AceitarTokenDoNavegador(funcao)
+		}
+		r.HandleFunc(rota.URI, funcao).Methods(rota.Metodo)
 	}
 	return r
 }
@@ -0,0 +1,27 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotasPalavrasSilenciadas = []Rota{
+	{
+		URI:                "/palavras-silenciadas",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.SilenciarPalavra,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/palavras-silenciadas",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarPalavrasSilenciadas,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/palavras-silenciadas/{palavraId}",
+		Metodo:             http.MethodDelete,
+		Funcao:             controllers.DesfazerSilenciamentoDePalavra,
+		RequerAutenticacao: true,
+	},
+}
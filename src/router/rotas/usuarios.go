@@ -18,6 +18,56 @@ var rotasUsuarios = []Rota{
 		Funcao:             controllers.BuscarUsuarios,
 		RequerAutenticacao: true,
 	},
+	//precisam vir antes de /usuarios/{usuarioId} para "sugestoes", "sugestoes-para-seguir", "bloqueados", "silenciados" e
+	//"pedidos-para-seguir" não serem lidos como um id
+	{
+		URI:                "/usuarios/sugestoes",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.SugerirUsuarios,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/sugestoes-para-seguir",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarSugestoesParaSeguir,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/sugestoes-para-seguir/{usuarioId}",
+		Metodo:             http.MethodDelete,
+		Funcao:             controllers.DispensarSugestaoParaSeguir,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/bloqueados",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarBloqueados,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/silenciados",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarSilenciados,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/pedidos-para-seguir",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarPedidosParaSeguir,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/pedidos-para-seguir/{usuarioId}/aprovar",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.AprovarPedidoParaSeguir,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/pedidos-para-seguir/{usuarioId}/rejeitar",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.RejeitarPedidoParaSeguir,
+		RequerAutenticacao: true,
+	},
 	{
 		URI:                "/usuarios/{usuarioId}",
 		Metodo:             http.MethodGet,
@@ -48,6 +98,42 @@ var rotasUsuarios = []Rota{
 		Funcao:             controllers.PararDeSeguirUsuario,
 		RequerAutenticacao: true,
 	},
+	{
+		URI:                "/usuarios/{usuarioId}/remover-seguidor",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.RemoverSeguidor,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/{usuarioId}/privacidade",
+		Metodo:             http.MethodPut,
+		Funcao:             controllers.AtualizarPrivacidade,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/{usuarioId}/bloquear",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.BloquearUsuario,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/{usuarioId}/desbloquear",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.DesbloquearUsuario,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/{usuarioId}/silenciar",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.SilenciarUsuario,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/{usuarioId}/desfazer-silenciamento",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.DesfazerSilenciamentoDeUsuario,
+		RequerAutenticacao: true,
+	},
 	{
 		URI:                "/usuarios/{usuarioId}/seguidores",
 		Metodo:             http.MethodGet,
@@ -11,3 +11,8 @@ func Hash(senha string) ([]byte, error) {
 func VerificarSenha(senhaHash, senhaString string) error {
 	return bcrypt.CompareHashAndPassword([]byte(senhaHash), []byte(senhaString))
 }
+
+// HashComCusto coloca hash numa senha com um custo do bcrypt diferente do padrão, mais baixo gera mais rápido
+func HashComCusto(senha string, custo int) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(senha), custo)
+}
@@ -0,0 +1,188 @@
+package semente
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+var primeirosNomes = []string{
+	"Ana", "Bruno", "Carla", "Daniel", "Eduarda", "Felipe", "Gabriela", "Henrique", "Isabela", "João",
+	"Larissa", "Lucas", "Mariana", "Mateus", "Natália", "Otávio", "Paula", "Rafael", "Sofia", "Tiago",
+	"Valentina", "Vinícius", "Yasmin", "Arthur", "Beatriz", "Caio", "Débora", "Enzo", "Fernanda", "Gustavo",
+}
+
+var sobrenomes = []string{
+	"Silva", "Santos", "Oliveira", "Souza", "Rodrigues", "Ferreira", "Alves", "Pereira", "Lima", "Gomes",
+	"Costa", "Ribeiro", "Martins", "Carvalho", "Almeida", "Lopes", "Soares", "Fernandes", "Vieira", "Barbosa",
+}
+
+var assuntos = []string{
+	"café", "futebol", "trabalho", "viagem", "música", "filme", "série", "praia", "chuva", "academia",
+	"livro", "jogo", "receita", "show", "golang", "faculdade", "feriado", "cachorro", "gato", "pizza",
+}
+
+var frases = []string{
+	"Hoje foi um dia e tanto com %s.",
+	"Alguém mais pensando em %s agora?",
+	"Não consigo parar de falar sobre %s.",
+	"Opinião impopular: %s é superestimado.",
+	"Melhor parte da semana foi %s.",
+	"Quem tem dica de %s me chama.",
+	"Mais um domingo, mais %s.",
+	"Acabei de descobrir uma coisa nova sobre %s!",
+}
+
+// usuarioGerado é um usuário que ainda não foi inserido no banco
+type usuarioGerado struct {
+	id       uint64
+	nome     string
+	nick     string
+	email    string
+	criadoEm time.Time
+}
+
+// curtidaGerada é uma curtida que ainda não foi inserida no banco
+type curtidaGerada struct {
+	usuarioID    uint64
+	publicacaoID uint64
+	criadoEm     time.Time
+}
+
+// publicacaoGerada é uma publicação que ainda não foi inserida no banco
+type publicacaoGerada struct {
+	id       uint64
+	titulo   string
+	conteudo string
+	autorID  uint64
+	curtidas uint64
+	criadoEm time.Time
+}
+
+// gerador produz os dados sintéticos, todo sorteio passa por aleatorio para a saída depender só da semente
+type gerador struct {
+	aleatorio  *rand.Rand
+	referencia time.Time
+	dias       int
+}
+
+// gerarUsuarios cria quantidade usuários com ids a partir de primeiroID, cadastrados antes do período das publicações
+func (g *gerador) gerarUsuarios(quantidade int, primeiroID uint64) []usuarioGerado {
+	//sorteando as datas antes e ordenando para os ids crescerem junto com a data de cadastro
+	datas := make([]time.Time, quantidade)
+	for i := range datas {
+		datas[i] = g.referencia.AddDate(0, 0, -g.dias-g.aleatorio.Intn(g.dias+1)).Add(g.horario())
+	}
+	sort.Slice(datas, func(i, j int) bool { return datas[i].Before(datas[j]) })
+	usuarios := make([]usuarioGerado, quantidade)
+	for i := range usuarios {
+		id := primeiroID + uint64(i)
+		nome := primeirosNomes[g.aleatorio.Intn(len(primeirosNomes))]
+		sobrenome := sobrenomes[g.aleatorio.Intn(len(sobrenomes))]
+		//o id no nick garante que ele é único sem precisar consultar o banco
+		nick := fmt.Sprintf("%.8s_%d", sobrenome, id)
+		usuarios[i] = usuarioGerado{
+			id:       id,
+			nome:     nome + " " + sobrenome,
+			nick:     nick,
+			email:    nick + "@exemplo.com",
+			criadoEm: datas[i],
+		}
+	}
+	return usuarios
+}
+
+// gerarSeguidores monta um grafo de seguidores com distribuição de lei de potência:
+// poucos usuários têm muitos seguidores e a maioria segue e é seguida por poucos
+func (g *gerador) gerarSeguidores(usuarios []usuarioGerado, mediaSeguindo int) [][2]uint64 {
+	quantidade := len(usuarios)
+	if quantidade < 2 {
+		return nil
+	}
+	//a popularidade é uma permutação dos usuários, assim os mais seguidos não são sempre os de menor id
+	popularidade := g.aleatorio.Perm(quantidade)
+	zipf := rand.NewZipf(g.aleatorio, 1.2, 1, uint64(quantidade-1))
+	var seguidores [][2]uint64
+	for i, seguidor := range usuarios {
+		//quantos cada um segue também varia bastante, com média perto de mediaSeguindo
+		quantosSegue := int(g.aleatorio.ExpFloat64()*float64(mediaSeguindo)) + 1
+		if quantosSegue > quantidade-1 {
+			quantosSegue = quantidade - 1
+		}
+		seguidos := make(map[int]bool, quantosSegue)
+		for tentativas := 0; len(seguidos) < quantosSegue && tentativas < quantosSegue*10; tentativas++ {
+			seguido := popularidade[zipf.Uint64()]
+			if seguido == i || seguidos[seguido] {
+				continue
+			}
+			seguidos[seguido] = true
+			seguidores = append(seguidores, [2]uint64{usuarios[seguido].id, seguidor.id})
+		}
+	}
+	return seguidores
+}
+
+// gerarPublicacoes cria as publicações de cada usuário espalhadas pelo período, com quantidade de curtidas
+// proporcional a quantos seguidores o autor tem
+func (g *gerador) gerarPublicacoes(usuarios []usuarioGerado, seguidores [][2]uint64, mediaPorUsuario int, primeiroID uint64) []publicacaoGerada {
+	quantidadeDeSeguidores := make(map[uint64]int, len(usuarios))
+	for _, par := range seguidores {
+		quantidadeDeSeguidores[par[0]]++
+	}
+	var publicacoes []publicacaoGerada
+	for _, autor := range usuarios {
+		quantidade := int(g.aleatorio.ExpFloat64() * float64(mediaPorUsuario))
+		for i := 0; i < quantidade; i++ {
+			assunto := assuntos[g.aleatorio.Intn(len(assuntos))]
+			criadoEm := g.referencia.AddDate(0, 0, -g.aleatorio.Intn(g.dias)).Add(g.horario())
+			//a fração de seguidores que curte varia entre publicações, algumas viralizam
+			alcance := math.Min(1, g.aleatorio.ExpFloat64()*0.1)
+			publicacoes = append(publicacoes, publicacaoGerada{
+				titulo:   fmt.Sprintf("Sobre %s", assunto),
+				conteudo: fmt.Sprintf(frases[g.aleatorio.Intn(len(frases))], assunto),
+				autorID:  autor.id,
+				curtidas: uint64(alcance * float64(quantidadeDeSeguidores[autor.id])),
+				criadoEm: criadoEm,
+			})
+		}
+	}
+	//o feed é ordenado por id, então os ids precisam seguir a ordem das datas
+	sort.SliceStable(publicacoes, func(i, j int) bool { return publicacoes[i].criadoEm.Before(publicacoes[j].criadoEm) })
+	for i := range publicacoes {
+		publicacoes[i].id = primeiroID + uint64(i)
+	}
+	return publicacoes
+}
+
+// horario sorteia um momento do dia, mais provável à noite e quase nunca de madrugada
+func (g *gerador) horario() time.Duration {
+	for {
+		hora := g.aleatorio.Float64() * 24
+		//curva com pico às 20h e vale às 4h
+		peso := 0.55 + 0.45*math.Cos((hora-20)/24*2*math.Pi)
+		if g.aleatorio.Float64() < peso {
+			return time.Duration(hora * float64(time.Hour)).Truncate(time.Second)
+		}
+	}
+}
+
+// gerarCurtidas escolhe, para cada publicação, quais seguidores do autor deram as curtidas dela
+func (g *gerador) gerarCurtidas(publicacoes []publicacaoGerada, seguidores [][2]uint64) []curtidaGerada {
+	seguidoresPorAutor := map[uint64][]uint64{}
+	for _, par := range seguidores {
+		seguidoresPorAutor[par[0]] = append(seguidoresPorAutor[par[0]], par[1])
+	}
+	var curtidas []curtidaGerada
+	for _, publicacao := range publicacoes {
+		candidatos := seguidoresPorAutor[publicacao.autorID]
+		quantidade := min(int(publicacao.curtidas), len(candidatos))
+		for _, i := range g.aleatorio.Perm(len(candidatos))[:quantidade] {
+			//as curtidas chegam nas primeiras horas depois da publicação
+			atraso := time.Duration(g.aleatorio.ExpFloat64() * float64(3*time.Hour)).Truncate(time.Second)
+			curtidas = append(curtidas, curtidaGerada{candidatos[i], publicacao.id, publicacao.criadoEm.Add(atraso)})
+		}
+	}
+	return curtidas
+}
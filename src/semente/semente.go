@@ -0,0 +1,186 @@
+package semente
+
+import (
+	"api/src/banco"
+	"api/src/busca"
+	"api/src/repositorios"
+	"api/src/seguranca"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Executar roda o comando "api seed", que enche o banco com usuários, seguidores, publicações e curtidas sintéticos.
+// Para a mesma semente e a mesma data de referência os dados gerados são sempre os mesmos
+func Executar(argumentos []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	quantidadeDeUsuarios := flags.Int("usuarios", 1000, "quantidade de usuários gerados")
+	semente := flags.Int64("semente", 1, "semente do gerador aleatório, a mesma semente gera os mesmos dados")
+	mediaSeguindo := flags.Int("seguindo", 30, "média de quantos usuários cada usuário segue")
+	mediaPublicacoes := flags.Int("publicacoes", 10, "média de publicações por usuário")
+	dias := flags.Int("dias", 90, "quantos dias antes da referência as publicações cobrem")
+	referencia := flags.String("referencia", "2024-06-01", "data (AAAA-MM-DD) da publicação mais recente possível")
+	senha := flags.String("senha", "senha123", "senha de todos os usuários gerados")
+	custo := flags.Int("custo-bcrypt", bcrypt.MinCost, "custo do bcrypt usado nas senhas")
+	tamanhoDoLote := flags.Int("lote", 500, "quantidade de linhas por insert")
+	if erro := flags.Parse(argumentos); erro != nil {
+		return erro
+	}
+	if *quantidadeDeUsuarios <= 0 || *dias <= 0 || *tamanhoDoLote <= 0 {
+		return fmt.Errorf("usuarios, dias e lote precisam ser maiores que zero")
+	}
+	dataDeReferencia, erro := time.ParseInLocation("2006-01-02", *referencia, time.Local)
+	if erro != nil {
+		return fmt.Errorf("referencia inválida: %w", erro)
+	}
+
+	db, erro := banco.Conectar()
+	if erro != nil {
+		return erro
+	}
+	defer db.Close()
+	//os ids são definidos aqui para os relacionamentos já saírem prontos sem consultar o banco de novo
+	primeiroUsuarioID, erro := proximoID(db, "usuarios")
+	if erro != nil {
+		return erro
+	}
+	primeiraPublicacaoID, erro := proximoID(db, "publicacoes")
+	if erro != nil {
+		return erro
+	}
+
+	g := gerador{aleatorio: rand.New(rand.NewSource(*semente)), referencia: dataDeReferencia, dias: *dias}
+	usuarios := g.gerarUsuarios(*quantidadeDeUsuarios, primeiroUsuarioID)
+	seguidores := g.gerarSeguidores(usuarios, *mediaSeguindo)
+	publicacoes := g.gerarPublicacoes(usuarios, seguidores, *mediaPublicacoes, primeiraPublicacaoID)
+	curtidas := g.gerarCurtidas(publicacoes, seguidores)
+	log.Printf("gerados %d usuários, %d seguidores, %d publicações e %d curtidas", len(usuarios), len(seguidores), len(publicacoes), len(curtidas))
+
+	senhas, erro := gerarSenhas(len(usuarios), *senha, *custo)
+	if erro != nil {
+		return erro
+	}
+
+	//tudo numa transação só, se algo falhar o banco não fica com metade dos dados
+	tx, erro := db.Begin()
+	if erro != nil {
+		return erro
+	}
+	erro = inserirEmLotes(tx, "insert into usuarios (id, nome, nick, email, senha, criadoem, nome_busca, nick_busca) values", 8, len(usuarios), *tamanhoDoLote,
+		func(i int) []interface{} {
+			u := usuarios[i]
+			return []interface{}{u.id, u.nome, u.nick, u.email, senhas[i], u.criadoEm, busca.Normalizar(u.nome), busca.Normalizar(u.nick)}
+		})
+	if erro == nil {
+		erro = inserirEmLotes(tx, "insert into seguidores (usuario_id, seguidor_id) values", 2, len(seguidores), *tamanhoDoLote,
+			func(i int) []interface{} {
+				return []interface{}{seguidores[i][0], seguidores[i][1]}
+			})
+	}
+	if erro == nil {
+		erro = inserirEmLotes(tx, "insert into publicacoes (id, titulo, conteudo, autor_id, curtidas, criadoEm) values", 6, len(publicacoes), *tamanhoDoLote,
+			func(i int) []interface{} {
+				p := publicacoes[i]
+				return []interface{}{p.id, p.titulo, p.conteudo, p.autorID, p.curtidas, p.criadoEm}
+			})
+	}
+	if erro == nil {
+		erro = inserirEmLotes(tx, "insert into curtidas (usuario_id, publicacao_id, criadoEm) values", 3, len(curtidas), *tamanhoDoLote,
+			func(i int) []interface{} {
+				c := curtidas[i]
+				return []interface{}{c.usuarioID, c.publicacaoID, c.criadoEm}
+			})
+	}
+	if erro == nil {
+		//a primeira revisão de cada publicação é o próprio conteúdo dela
+		erro = inserirEmLotes(tx, "insert into revisoes_publicacoes (publicacao_id, titulo, conteudo, criadoEm) values", 4, len(publicacoes), *tamanhoDoLote,
+			func(i int) []interface{} {
+				p := publicacoes[i]
+				return []interface{}{p.id, p.titulo, p.conteudo, p.criadoEm}
+			})
+	}
+	if erro != nil {
+		tx.Rollback()
+		return erro
+	}
+	if erro = tx.Commit(); erro != nil {
+		return erro
+	}
+	//os dados entram direto nas tabelas, então os contadores dos usuários são calculados no fim
+	if _, erro = repositorios.NovoRepositorioDeUsuarios(db).RecalcularContadores(); erro != nil {
+		return erro
+	}
+	log.Printf("dados inseridos, todos os usuários têm a senha %q", *senha)
+	return nil
+}
+
+// proximoID retorna o id que a próxima linha inserida em tabela teria
+func proximoID(db *sql.DB, tabela string) (uint64, error) {
+	var maiorID uint64
+	if erro := db.QueryRow("select coalesce(max(id), 0) from " + tabela).Scan(&maiorID); erro != nil {
+		return 0, erro
+	}
+	return maiorID + 1, nil
+}
+
+// gerarSenhas coloca hash na senha uma vez para cada usuário, em paralelo porque o bcrypt é lento de propósito
+func gerarSenhas(quantidade int, senha string, custo int) ([]string, error) {
+	senhas := make([]string, quantidade)
+	indices := make(chan int)
+	erros := make(chan error, 1)
+	var grupo sync.WaitGroup
+	for trabalhador := 0; trabalhador < runtime.NumCPU(); trabalhador++ {
+		grupo.Add(1)
+		go func() {
+			defer grupo.Done()
+			for i := range indices {
+				senhaHash, erro := seguranca.HashComCusto(senha, custo)
+				if erro != nil {
+					select {
+					case erros <- erro:
+					default:
+					}
+					continue
+				}
+				senhas[i] = string(senhaHash)
+			}
+		}()
+	}
+	for i := 0; i < quantidade; i++ {
+		indices <- i
+	}
+	close(indices)
+	grupo.Wait()
+	select {
+	case erro := <-erros:
+		return nil, erro
+	default:
+		return senhas, nil
+	}
+}
+
+// inserirEmLotes executa comando com várias linhas de valores por vez, tamanhoDoLote linhas em cada insert
+func inserirEmLotes(tx *sql.Tx, comando string, colunas, quantidade, tamanhoDoLote int, valores func(i int) []interface{}) error {
+	marcador := "(" + strings.TrimSuffix(strings.Repeat("?,", colunas), ",") + ")"
+	for inicio := 0; inicio < quantidade; inicio += tamanhoDoLote {
+		fim := min(inicio+tamanhoDoLote, quantidade)
+		marcadores := make([]string, 0, fim-inicio)
+		argumentos := make([]interface{}, 0, (fim-inicio)*colunas)
+		for i := inicio; i < fim; i++ {
+			marcadores = append(marcadores, marcador)
+			argumentos = append(argumentos, valores(i)...)
+		}
+		if _, erro := tx.Exec(comando+" "+strings.Join(marcadores, ","), argumentos...); erro != nil {
+			return erro
+		}
+	}
+	return nil
+}
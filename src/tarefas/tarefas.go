@@ -0,0 +1,179 @@
+package tarefas
+
+import (
+	"api/src/armazenamento"
+	"api/src/banco"
+	"api/src/config"
+	"api/src/repositorios"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// Iniciar começa as tarefas periódicas da api em segundo plano
+func Iniciar() {
+	go repetir("purgar publicações deletadas", time.Hour, purgarPublicacoesDeletadas)
+	go repetir("calcular hashtags em alta", 5*time.Minute, calcularHashtagsEmAlta)
+	go repetir("purgar silenciamentos acabados", time.Hour, purgarSilenciamentosAcabados)
+	go repetir("purgar anexos órfãos", time.Hour, purgarAnexosOrfaos)
+	go repetir("recalcular contadores", 24*time.Hour, recalcularContadores)
+	go repetir("calcular sugestões para seguir", time.Minute, calcularSugestoesParaSeguir)
+}
+
+// esperaPorAnexosOrfaos é quanto tempo um anexo enviado pode ficar sem publicação antes de ser apagado
+const esperaPorAnexosOrfaos = 24 * time.Hour
+
+// loteDeSugestoes é de quantos usuários as sugestões para seguir pendentes são lidas por vez
+const loteDeSugestoes = 500
+
+// repetir executa tarefa agora e depois a cada intervalo, registrando os erros sem parar
+func repetir(nome string, intervalo time.Duration, tarefa func() error) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+	for {
+		if erro := tarefa(); erro != nil {
+			log.Printf("erro na tarefa %s: %v", nome, erro)
+		}
+		<-ticker.C
+	}
+}
+
+// purgarPublicacoesDeletadas apaga de vez as publicações deletadas há mais tempo que a retenção configurada
+func purgarPublicacoesDeletadas() error {
+	db, erro := banco.Conectar()
+	if erro != nil {
+		return erro
+	}
+	defer db.Close()
+	repositorio := repositorios.NovoRepositorioDePublicacoes(db)
+	apagadas, erro := repositorio.PurgarDeletadas(time.Now().Add(-config.RetencaoDePublicacoesDeletadas))
+	if erro != nil {
+		return erro
+	}
+	if apagadas > 0 {
+		log.Printf("%d publicações deletadas foram apagadas de vez", apagadas)
+	}
+	return nil
+}
+
+// calcularHashtagsEmAlta refaz a lista de hashtags em alta com as publicações da janela configurada
+func calcularHashtagsEmAlta() error {
+	db, erro := banco.Conectar()
+	if erro != nil {
+		return erro
+	}
+	defer db.Close()
+	hashtags, erro := repositorios.NovoRepositorioDeHashtags(db).CalcularEmAlta(time.Now(), config.JanelaDeHashtagsEmAlta)
+	if erro != nil {
+		return erro
+	}
+	return repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+		return transacao.Hashtags.SalvarEmAlta(hashtags)
+	})
+}
+
+// purgarSilenciamentosAcabados apaga os silenciamentos de contas e palavras que já acabaram, que as buscas já ignoram
+func purgarSilenciamentosAcabados() error {
+	db, erro := banco.Conectar()
+	if erro != nil {
+		return erro
+	}
+	defer db.Close()
+	_, erro = repositorios.NovoRepositorioDeUsuarios(db).PurgarSilenciamentosAcabados(time.Now())
+	return erro
+}
+
+// purgarAnexosOrfaos apaga os anexos que ficaram sem publicação, junto com os arquivos deles.
+// O banco só é limpo depois dos arquivos, então uma falha no armazenamento é tentada de novo na próxima vez
+func purgarAnexosOrfaos() error {
+	db, erro := banco.Conectar()
+	if erro != nil {
+		return erro
+	}
+	defer db.Close()
+	repositorio := repositorios.NovoRepositorioDeAnexos(db)
+	anexos, erro := repositorio.BuscarOrfaos(time.Now().Add(-esperaPorAnexosOrfaos))
+	if erro != nil {
+		return erro
+	}
+	for _, anexo := range anexos {
+		if erro = armazenamento.Remover(anexo.Chave, anexo.ChaveMiniatura); erro != nil {
+			return erro
+		}
+		if erro = repositorio.Deletar(anexo.ID); erro != nil {
+			return erro
+		}
+	}
+	if len(anexos) > 0 {
+		log.Printf("%d anexos órfãos foram apagados", len(anexos))
+	}
+	return nil
+}
+
+// recalcularContadores corrige os contadores de usuários (seguidores, seguindo e publicações) e de publicações
+// (curtidas, comentários, republicações e citações) que tenham se desviado da contagem real
+func recalcularContadores() error {
+	db, erro := banco.Conectar()
+	if erro != nil {
+		return erro
+	}
+	defer db.Close()
+	corrigidos, erro := repositorios.NovoRepositorioDeUsuarios(db).RecalcularContadores()
+	if corrigidos > 0 {
+		log.Printf("contadores de %d usuários foram corrigidos", corrigidos)
+	}
+	if erro != nil {
+		return erro
+	}
+	corrigidos, erro = repositorios.NovoRepositorioDePublicacoes(db).RecalcularContadores()
+	if corrigidos > 0 {
+		log.Printf("contadores de %d publicações foram corrigidos", corrigidos)
+	}
+	return erro
+}
+
+// calcularSugestoesParaSeguir refaz as sugestões de quem seguir só dos usuários que seguiram ou deixaram de seguir
+// alguém e de parte dos seguidores deles, lendo lotes até não sobrar nenhum pendente, um usuário por transação
+// para não segurar as tabelas
+func calcularSugestoesParaSeguir() error {
+	db, erro := banco.Conectar()
+	if erro != nil {
+		return erro
+	}
+	defer db.Close()
+	//primeiro marcando os seguidores, para as sugestões deles entrarem nos lotes desta mesma execução
+	erro = processarPendentes(db, repositorios.NovoRepositorioDeUsuarios(db).BuscarSugestoesDosSeguidoresPendentes,
+		func(transacao repositorios.Transacao, usuarioID uint64) error {
+			return transacao.Usuarios.DesatualizarSugestoesDosSeguidores(usuarioID)
+		})
+	if erro != nil {
+		return erro
+	}
+	return processarPendentes(db, repositorios.NovoRepositorioDeUsuarios(db).BuscarSugestoesPendentes,
+		func(transacao repositorios.Transacao, usuarioID uint64) error {
+			return transacao.Usuarios.CalcularSugestoesParaSeguir(usuarioID)
+		})
+}
+
+// processarPendentes lê lotes de loteDeSugestoes usuários com buscar e roda processar para cada um na sua transação,
+// até um lote vir incompleto
+func processarPendentes(db *sql.DB, buscar func(limite int) ([]uint64, error),
+	processar func(transacao repositorios.Transacao, usuarioID uint64) error) error {
+	for {
+		usuariosIDs, erro := buscar(loteDeSugestoes)
+		if erro != nil {
+			return erro
+		}
+		for _, usuarioID := range usuariosIDs {
+			erro = repositorios.ExecutarTransacao(db, func(transacao repositorios.Transacao) error {
+				return processar(transacao, usuarioID)
+			})
+			if erro != nil {
+				return erro
+			}
+		}
+		if len(usuariosIDs) < loteDeSugestoes {
+			return nil
+		}
+	}
+}